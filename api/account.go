@@ -2,8 +2,12 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/elmas23/simplebank/money"
+	"github.com/elmas23/simplebank/token"
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 	"net/http"
 )
 
@@ -13,13 +17,14 @@ import (
 // But we won't need the balance field since when an account is created
 // the balance should be zero
 
-// So we will only allow the client to specify the owner's name and the currency of the account
+// So we will only allow the client to specify the currency of the account
+// The owner is no longer part of the request: it is the user of the access token,
+// so a user can only create accounts for himself
 // We will also validate those input
 // binding: "required" means that this field is required otherwise it's a bad request
-// binding: "oneof= X Y Z" means that field can only have value X, Y, or Z. Otherwise it is a bad request
+// binding: "currency" is our custom validator, the field must be one of the supported currencies of the config
 type createAccountRequest struct {
-	Owner    string `json:"owner" binding:"required"`
-	Currency string `json:"currency" binding:"required,oneof=USD EUR"`
+	Currency string `json:"currency" binding:"required,currency"`
 }
 
 // Since ID is a URI parameter, we cannot get it from the request body
@@ -30,13 +35,6 @@ type getAccountRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
-// we don't want the max number of elements to be too small or too big
-// That's why we use min and max in the binding
-type listAccountRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
-}
-
 // The reason why we are passing the gin context
 // is because the handler function of the POST methods from the router is declared as a fucntion
 // with a context input.
@@ -54,16 +52,51 @@ func (server *Server) createAccount(ctx *gin.Context) {
 	// In case there is no error
 	// we simply insert the new account into the database
 
+	// the auth middleware stored the payload of the access token in the context
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// If the client sent an idempotency key that was already used, we return the saved response
+	idempotency, err := idempotencyParams(ctx, authPayload.Username, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if server.replayIdempotentRequest(ctx, idempotency) {
+		return
+	}
+
 	// we construct the params using information from the request
 	arg := db.CreateAccountParams{
-		Owner:    req.Owner,
+		Owner:    authPayload.Username,
 		Currency: req.Currency,
 		Balance:  0,
 	}
 
 	// Here use the server to access store.CreateAccount to insert the new accounts into the database
-	account, err := server.store.CreateAccount(ctx, arg)
+	// With an idempotency key, the key is saved in the same transaction as the account
+	var account db.Account
+	if idempotency == nil {
+		account, err = server.store.CreateAccount(ctx, arg)
+	} else {
+		account, err = server.store.CreateAccountTx(ctx, db.CreateAccountTxParams{
+			CreateAccountParams: arg,
+			Idempotency:         idempotency,
+		})
+	}
 	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyExists) {
+			server.handleIdempotencyKeyExists(ctx, idempotency)
+			return
+		}
+		// The owner must be an existing user, and a user can only have one account per currency
+		// Violating one of those constraints is a client error, so we return Forbidden
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "foreign_key_violation", "unique_violation":
+				ctx.JSON(http.StatusForbidden, errorResponse(err))
+				return
+			}
+		}
 		// If there is an error, we return an Internal Server Error now instead of a bad request error
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -101,24 +134,40 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
+	// A user can only get his own accounts
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
 	// Finally, if all is successful, we return status OK, with the account
 	ctx.JSON(http.StatusOK, account)
 }
 
 func (server *Server) listAccount(ctx *gin.Context) {
-	var req listAccountRequest
+	// the pagination parameters are the same for all the lists, see pagination.go
+	var req pageRequest
 	// Now here since we deal with query parameters, we use ShouldBindQuery
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
+	// we only list the accounts of the authenticated user
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// without a page_id, we use the cursor pagination
+	if req.PageID == 0 {
+		server.listAccountsAfter(ctx, authPayload.Username, req)
+		return
+	}
+
 	arg := db.ListAccountsParams{
+		Owner:  authPayload.Username,
 		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize, // offset is the number of records that the database should skip
-		// Thus we calculate that like above
-		// so if we start from page_id =1 , we will not skip anything
-		// if we start from page_id = 2, we will skip page_size elements
+		Offset: req.offset(), // offset is the number of records that the database should skip
 	}
 
 	accounts, err := server.store.ListAccounts(ctx, arg)
@@ -129,3 +178,125 @@ func (server *Server) listAccount(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, accounts)
 }
+
+// listAccountsAfter returns a page of accounts with the cursor pagination
+func (server *Server) listAccountsAfter(ctx *gin.Context, owner string, req pageRequest) {
+	position, err := decodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// we get one more account than asked, this is how we know if there is a next page
+	arg := db.ListAccountsAfterParams{
+		Owner:          owner,
+		AfterCreatedAt: position.CreatedAt,
+		AfterID:        position.ID,
+		Limit:          req.PageSize + 1,
+	}
+
+	accounts, err := server.store.ListAccountsAfter(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := pageResponse{Items: accounts}
+	if len(accounts) > int(req.PageSize) {
+		accounts = accounts[:req.PageSize]
+		last := accounts[len(accounts)-1]
+		response = pageResponse{Items: accounts, NextCursor: encodeCursor(last.CreatedAt, last.ID)}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// setOverdraftLimitRequest holds the new overdraft limit of an account
+// the limit is the positive amount that the balance is allowed to go below zero, in the minor unit of the currency
+// we use a pointer so that 0 is accepted as a value, but a missing field is not
+type setOverdraftLimitRequest struct {
+	OverdraftLimit *int64 `json:"overdraft_limit" binding:"required,min=0"`
+}
+
+// setOverdraftLimit gives a line of credit to an account
+// This route is only available to bankers
+func (server *Server) setOverdraftLimit(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setOverdraftLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.UpdateAccountOverdraftLimitParams{
+		ID:             uri.ID,
+		OverdraftLimit: money.Amount(*req.OverdraftLimit),
+	}
+
+	account, err := server.store.SetOverdraftLimit(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		// the account already owes more than the new limit
+		if errors.Is(err, db.ErrOverdraftLimitTooLow) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+// setAccountStatusRequest holds the new status of an account
+// an active account can be frozen or closed, a frozen account can be unfrozen (active) or closed
+type setAccountStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active frozen closed"`
+}
+
+// setAccountStatus freezes, unfreezes or closes an account
+// Accounts are never deleted, since their entries and transfers must stay in the ledger
+// This route is only available to bankers
+func (server *Server) setAccountStatus(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setAccountStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.UpdateAccountStatusParams{
+		ID:     uri.ID,
+		Status: req.Status,
+	}
+
+	account, err := server.store.SetAccountStatus(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		// a closed account cannot be reopened, and an account with money cannot be closed
+		if errors.Is(err, db.ErrAccountClosed) || errors.Is(err, db.ErrAccountBalanceNotZero) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
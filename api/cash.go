@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"errors"
+	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/elmas23/simplebank/money"
+	"github.com/elmas23/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// cashRequest holds the input of a deposit or a withdrawal
+// The ID of the account comes from the URI, the amount and its currency from the JSON body
+// Like a transfer, the amount is in the minor unit of the currency and must be strictly positive,
+// and the currency must be the one of the account
+type cashRequest struct {
+	AccountID int64  `json:"account_id"` // always set from the URI, a value sent in the body is ignored
+	Amount    int64  `json:"amount" binding:"required,gt=0"`
+	Currency  string `json:"currency" binding:"required,currency"`
+}
+
+// cashFunc is the store transaction that moves the money, DepositTx or WithdrawTx
+type cashFunc func(ctx context.Context, arg db.CashTxParams) (db.CashTxResult, error)
+
+// createDeposit adds money coming from outside the bank to an account, it is made by the authenticated banker
+func (server *Server) createDeposit(ctx *gin.Context) {
+	server.moveCash(ctx, server.store.DepositTx)
+}
+
+// createWithdrawal takes money out of an account, it is made by the authenticated banker
+// It fails with 422 if the balance would go below the overdraft limit, like a transfer
+func (server *Server) createWithdrawal(ctx *gin.Context) {
+	server.moveCash(ctx, server.store.WithdrawTx)
+}
+
+// moveCash handles a deposit or a withdrawal, they only differ by the store transaction that is called
+func (server *Server) moveCash(ctx *gin.Context, cashTx cashFunc) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req cashRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	req.AccountID = uri.ID
+
+	// the ID of the account is copied into the request before it is hashed,
+	// otherwise the same key and body could replay the deposit of another account
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	idempotency, err := idempotencyParams(ctx, authPayload.Username, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if server.replayIdempotentRequest(ctx, idempotency) {
+		return
+	}
+
+	// The route is only open to the bankers, so the account can belong to any customer
+	if _, valid := server.validAccount(ctx, req.AccountID, req.Currency); !valid {
+		return
+	}
+
+	arg := db.CashTxParams{
+		AccountID:   req.AccountID,
+		Amount:      money.New(money.Amount(req.Amount), req.Currency),
+		CreatedBy:   authPayload.Username,
+		Idempotency: idempotency,
+	}
+
+	result, err := cashTx(ctx, arg)
+	if err != nil {
+		// The request is valid, but it cannot be processed with the current balance or status of the account
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrAccountNotActive) ||
+			errors.Is(err, money.ErrOverflow) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrIdempotencyKeyExists) {
+			server.handleIdempotencyKeyExists(ctx, idempotency)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
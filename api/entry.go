@@ -0,0 +1,110 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/elmas23/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// listEntries returns the entries of an account, that is every change of its balance
+// In the ledger, they are the postings of the account, see postJournalEntry
+// The entries are sorted from the oldest to the most recent one
+func (server *Server) listEntries(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the ID of the account comes from the URI, and the pagination from the query
+	var req pageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.ownedAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	// without a page_id, we use the cursor pagination
+	if req.PageID == 0 {
+		server.listEntriesAfter(ctx, uri.ID, req)
+		return
+	}
+
+	arg := db.ListPostingsParams{
+		AccountID: uri.ID,
+		Limit:     req.PageSize,
+		Offset:    req.offset(),
+	}
+
+	entries, err := server.store.ListPostings(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entries)
+}
+
+// listEntriesAfter returns a page of entries with the cursor pagination
+func (server *Server) listEntriesAfter(ctx *gin.Context, accountID int64, req pageRequest) {
+	position, err := decodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// we get one more entry than asked, this is how we know if there is a next page
+	arg := db.ListPostingsAfterParams{
+		AccountID:      accountID,
+		AfterCreatedAt: position.CreatedAt,
+		AfterID:        position.ID,
+		Limit:          req.PageSize + 1,
+	}
+
+	entries, err := server.store.ListPostingsAfter(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := pageResponse{Items: entries}
+	if len(entries) > int(req.PageSize) {
+		entries = entries[:req.PageSize]
+		last := entries[len(entries)-1]
+		response = pageResponse{Items: entries, NextCursor: encodeCursor(last.CreatedAt, last.ID)}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// ownedAccount checks that the account with the given ID exists and belongs to the authenticated user
+// If the check fails, the error response is written directly to the context and false is returned,
+// so the caller only has to stop processing the request
+func (server *Server) ownedAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	// A user can only see the history of his own accounts
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/elmas23/simplebank/money"
+	"github.com/elmas23/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// A hold reserves money on the account of the payer until the receiver captures it, see CreateHoldTx
+// The payer creates the hold, and only the owner of the receiving account can capture or release it,
+// like a merchant who is paid by card. The owners of both accounts can see it
+
+// holdRequest holds the input of a new hold
+// Like a transfer, the amount is in the minor unit of the currency, which must be the one of the payer's account
+type holdRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+}
+
+// createHold reserves money on an account of the authenticated user for the receiving account
+func (server *Server) createHold(ctx *gin.Context) {
+	var req holdRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.FromAccountID == req.ToAccountID {
+		err := errors.New("a hold cannot be payable to its own account")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	idempotency, err := idempotencyParams(ctx, authPayload.Username, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if server.replayIdempotentRequest(ctx, idempotency) {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	// A user can only reserve money on his own accounts
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	// the receiver's currency is not checked, the captures are converted like any other transfer
+	if _, valid = server.existingAccount(ctx, req.ToAccountID); !valid {
+		return
+	}
+
+	arg := db.CreateHoldTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        money.New(money.Amount(req.Amount), req.Currency),
+		CreatedBy:     authPayload.Username,
+		Idempotency:   idempotency,
+	}
+
+	hold, err := server.store.CreateHoldTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyExists) {
+			server.handleIdempotencyKeyExists(ctx, idempotency)
+			return
+		}
+		ctx.JSON(holdErrorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+// holdErrorResponse returns the status code and the response of an error of a hold transaction
+// The hold cannot be used anymore, or the capture fails like a transfer
+func holdErrorResponse(err error) (int, gin.H) {
+	if errors.Is(err, db.ErrHoldNotActive) || errors.Is(err, db.ErrHoldExpired) ||
+		errors.Is(err, db.ErrHoldAmountExceeded) || errors.Is(err, db.ErrHoldRequiresApproval) {
+		return http.StatusUnprocessableEntity, errorResponse(err)
+	}
+	return transferErrorResponse(err)
+}
+
+// getHoldRequest holds the ID of the hold, which is a URI parameter
+type getHoldRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// holdResponse is a hold with its captures, oldest first
+// the transfer of each capture can be read with the transfer routes
+type holdResponse struct {
+	Hold     db.Hold          `json:"hold"`
+	Captures []db.HoldCapture `json:"captures"`
+}
+
+// getHold returns a single hold with its captures
+// Like a transfer, a hold can be seen by the owner of either of its accounts
+func (server *Server) getHold(ctx *gin.Context) {
+	var req getHoldRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, valid := server.existingHold(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	for _, accountID := range []int64{hold.AccountID, hold.ToAccountID} {
+		account, err := server.store.GetAccount(ctx, accountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if account.Owner != authPayload.Username {
+			continue
+		}
+
+		captures, err := server.store.ListHoldCaptures(ctx, hold.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusOK, holdResponse{Hold: hold, Captures: captures})
+		return
+	}
+
+	err := errors.New("hold doesn't belong to the authenticated user")
+	ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+}
+
+// captureHoldRequest holds the amount to capture, in the minor unit of the currency of the hold
+// The amount is optional, what is left of the hold is captured when it is not sent
+type captureHoldRequest struct {
+	HoldID int64 `json:"hold_id"` // always set from the URI, a value sent in the body is ignored
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// captureHold takes all or part of the money reserved by a hold, with a transfer to the receiving account
+// The response is the one of a transfer, with the hold after the capture
+func (server *Server) captureHold(ctx *gin.Context) {
+	var uri getHoldRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req captureHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	req.HoldID = uri.ID
+
+	// like a deposit, the ID of the hold is hashed with the request, so the same key cannot capture another hold
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	idempotency, err := idempotencyParams(ctx, authPayload.Username, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if server.replayIdempotentRequest(ctx, idempotency) {
+		return
+	}
+
+	if _, valid := server.receivedHold(ctx, req.HoldID); !valid {
+		return
+	}
+
+	arg := db.CaptureHoldTxParams{
+		HoldID:      req.HoldID,
+		Amount:      money.Amount(req.Amount),
+		Idempotency: idempotency,
+	}
+
+	result, err := server.store.CaptureHoldTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyExists) {
+			server.handleIdempotencyKeyExists(ctx, idempotency)
+			return
+		}
+		ctx.JSON(holdErrorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// releaseHold gives back to the payer what was not captured of a hold
+func (server *Server) releaseHold(ctx *gin.Context) {
+	var req getHoldRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.receivedHold(ctx, req.ID); !valid {
+		return
+	}
+
+	hold, err := server.store.ReleaseHoldTx(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(holdErrorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+// holdFilterRequest holds the optional status of the listed holds
+// without it, the holds of every status are listed
+type holdFilterRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=active captured released expired"`
+}
+
+// listHolds returns the holds on an account, oldest first, with the cursor pagination
+// example: http://localhost:8080/accounts/1/holds?status=active&page_size=10
+func (server *Server) listHolds(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req pageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var filter holdFilterRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// this list is new, so it only has the cursor pagination
+	if req.PageID != 0 {
+		err := errors.New("holds use the cursor pagination, page_id is not supported")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	position, err := decodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.ownedAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	// we get one more hold than asked, this is how we know if there is a next page
+	arg := db.ListHoldsParams{
+		AccountID:      uri.ID,
+		Status:         sql.NullString{String: filter.Status, Valid: filter.Status != ""},
+		AfterCreatedAt: position.CreatedAt,
+		AfterID:        position.ID,
+		Limit:          req.PageSize + 1,
+	}
+
+	holds, err := server.store.ListHolds(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := pageResponse{Items: holds}
+	if len(holds) > int(req.PageSize) {
+		holds = holds[:req.PageSize]
+		last := holds[len(holds)-1]
+		response = pageResponse{Items: holds, NextCursor: encodeCursor(last.CreatedAt, last.ID)}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// existingHold checks that the hold with the given ID exists
+// Like existingAccount, the error response is written directly to the context when it returns false
+func (server *Server) existingHold(ctx *gin.Context, holdID int64) (db.Hold, bool) {
+	hold, err := server.store.GetHold(ctx, holdID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return hold, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, false
+	}
+	return hold, true
+}
+
+// receivedHold checks that the hold exists and that it is payable to an account of the authenticated user,
+// only that user can capture or release it
+// The error response is written directly to the context when it returns false
+func (server *Server) receivedHold(ctx *gin.Context, holdID int64) (db.Hold, bool) {
+	hold, valid := server.existingHold(ctx, holdID)
+	if !valid {
+		return hold, false
+	}
+
+	toAccount, err := server.store.GetAccount(ctx, hold.ToAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if toAccount.Owner != authPayload.Username {
+		err := errors.New("hold is not payable to an account of the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return hold, false
+	}
+
+	return hold, true
+}
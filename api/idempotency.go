@@ -0,0 +1,103 @@
+package api
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// Mobile clients retry a request when it times out, but the first attempt may have succeeded
+// By sending the same Idempotency-Key header with each attempt, the client makes sure
+// that the request is only processed once. The other attempts get the original response back
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotencyParams reads the idempotency key of the request and computes the hash of the request
+// It returns nil when the client didn't send a key, in that case the request is processed as usual
+// We hash the bound request instead of the raw body, so a retry that only changes the
+// formatting of the JSON is still considered the same request
+func idempotencyParams(ctx *gin.Context, username string, req interface{}) (*db.IdempotencyParams, error) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if len(key) == 0 {
+		return nil, nil
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, fmt.Errorf("idempotency key must be at most %d characters", maxIdempotencyKeyLength)
+	}
+
+	requestHash, err := hashRequest(ctx.Request.Method, ctx.FullPath(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &db.IdempotencyParams{
+		Username:       username,
+		Key:            key,
+		RequestHash:    requestHash,
+		ResponseStatus: http.StatusOK,
+		AcceptedStatus: http.StatusAccepted,
+	}, nil
+}
+
+// hashRequest returns the SHA-256 of a bound request as a hex string
+// the route is part of the hash, so the same key cannot be used for a transfer and an account
+func hashRequest(method string, route string, req interface{}) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + route + "\n"))
+	hash.Write(data)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// replayIdempotentRequest looks for a saved response with the same idempotency key
+// It returns true when the response has been written to the context, so the caller only has to stop processing the request:
+// the saved response is replayed if the request is the same, and 409 Conflict is returned otherwise
+// It returns false when the key was never used, or when the request has no idempotency key
+func (server *Server) replayIdempotentRequest(ctx *gin.Context, arg *db.IdempotencyParams) bool {
+	if arg == nil {
+		return false
+	}
+
+	idempotencyKey, err := server.store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: arg.Username,
+		Key:      arg.Key,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return true
+	}
+
+	if idempotencyKey.RequestHash != arg.RequestHash {
+		err := errors.New("idempotency key was already used for a different request")
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return true
+	}
+
+	// the body was saved with json.Marshal, which is also what ctx.JSON uses
+	// so the client receives exactly the same response as the first time
+	ctx.Data(int(idempotencyKey.ResponseStatus), "application/json; charset=utf-8", idempotencyKey.ResponseBody)
+	return true
+}
+
+// handleIdempotencyKeyExists is called when the key was taken by a concurrent request
+// between our first lookup and our transaction. That request is now committed, so we replay it
+func (server *Server) handleIdempotencyKeyExists(ctx *gin.Context, arg *db.IdempotencyParams) {
+	if !server.replayIdempotentRequest(ctx, arg) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(db.ErrIdempotencyKeyExists))
+	}
+}
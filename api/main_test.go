@@ -1,11 +1,31 @@
 package api
 
 import (
+	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/elmas23/simplebank/db/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
 	"os"
 	"testing"
+	"time"
 )
 
+// newTestServer creates a server with a random token key
+// so that every test does not need to load the real config
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := utils.Config{
+		SupportedCurrencies:  []string{"USD", "EUR", "CAD"}, // the currencies of utils.GenerateCurrency
+		TokenSymmetricKey:    utils.GenerateRandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+	}
+
+	server, err := NewServer(config, store)
+	require.NoError(t, err)
+
+	return server
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 	os.Exit(m.Run())
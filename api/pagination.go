@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+/*
+How do we paginate ?
+
+		There are 2 ways to get a list page by page:
+
+				- Offset pagination: the client sends page_id and page_size, and we skip (page_id - 1) * page_size rows.
+				  The database still has to read all the skipped rows, so the pages get slower as page_id grows.
+				  And if a new row is inserted while the client is reading the pages, a row is shown twice or skipped.
+				- Cursor (keyset) pagination: the client sends the next_cursor returned with the previous page,
+				  and we continue right after the last row of that page. The cursor holds the (created_at, id)
+				  of that row, which is also the order of the list, so an index gives the next rows directly.
+
+		If the client sends a page_id, the offset pagination is used and the response is the list of items,
+		like it has always been. Otherwise the cursor pagination is used, and the response is a pageResponse.
+*/
+
+// pageRequest holds the pagination parameters of every list route
+// we don't want the max number of elements to be too small or too big
+// That's why we use min and max in the binding
+// page_id and cursor cannot be used together, since they are two different kinds of pagination
+type pageRequest struct {
+	PageID   int32  `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=100"`
+	Cursor   string `form:"cursor" binding:"excluded_with=PageID"`
+}
+
+// offset returns the number of rows to skip in the offset pagination
+// so if we start from page_id = 1, we will not skip anything
+// if we start from page_id = 2, we will skip page_size elements
+func (req pageRequest) offset() int32 {
+	return (req.PageID - 1) * req.PageSize
+}
+
+// pageResponse is the response of a list route that uses the cursor pagination
+// next_cursor is empty when there are no more items
+type pageResponse struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor"`
+}
+
+// pageCursor is the position of the last item of a page
+type pageCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        int64     `json:"id"`
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor returns the opaque token given to the client
+// it is only base64 so it can be sent in a URL, the client must not rely on what is inside
+func encodeCursor(createdAt time.Time, id int64) string {
+	data, _ := json.Marshal(pageCursor{CreatedAt: createdAt, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads the cursor sent by the client
+// An empty cursor means the first page: the zero time and the id 0 are before any row
+func decodeCursor(cursor string) (pageCursor, error) {
+	var position pageCursor
+	if len(cursor) == 0 {
+		return position, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return position, errInvalidCursor
+	}
+	if err := json.Unmarshal(data, &position); err != nil {
+		return position, errInvalidCursor
+	}
+	return position, nil
+}
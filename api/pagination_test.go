@@ -0,0 +1,277 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/elmas23/simplebank/db/mock"
+	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+	id := utils.GenerateRandomInt(1, 1000)
+
+	cursor := encodeCursor(createdAt, id)
+	require.NotEmpty(t, cursor)
+
+	position, err := decodeCursor(cursor)
+	require.NoError(t, err)
+	require.True(t, createdAt.Equal(position.CreatedAt))
+	require.Equal(t, id, position.ID)
+
+	// an empty cursor is the first page
+	position, err = decodeCursor("")
+	require.NoError(t, err)
+	require.Zero(t, position.ID)
+	require.True(t, position.CreatedAt.IsZero())
+
+	_, err = decodeCursor("not a cursor")
+	require.ErrorIs(t, err, errInvalidCursor)
+}
+
+func TestListAccountsCursorAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	n := 5
+	accounts := make([]db.Account, n+1)
+	for i := range accounts {
+		accounts[i] = randomAccount(user.Username)
+		accounts[i].CreatedAt = time.Now().UTC().Truncate(time.Microsecond).Add(time.Duration(i) * time.Second)
+	}
+	last := accounts[n-1]
+	nextCursor := encodeCursor(last.CreatedAt, last.ID)
+
+	testCases := []struct {
+		name          string
+		query         map[string]string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "FirstPage",
+			query: map[string]string{"page_size": fmt.Sprint(n)},
+			buildStubs: func(store *mockdb.MockStore) {
+				// one more account is asked, to know if there is a next page
+				arg := db.ListAccountsAfterParams{
+					Owner: user.Username,
+					Limit: int32(n + 1),
+				}
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					ListAccountsAfter(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccountsPage(t, recorder.Body, accounts[:n], nextCursor)
+			},
+		},
+		{
+			name:  "LastPage",
+			query: map[string]string{"page_size": fmt.Sprint(n), "cursor": nextCursor},
+			buildStubs: func(store *mockdb.MockStore) {
+				// the next page starts after the account of the cursor
+				arg := db.ListAccountsAfterParams{
+					Owner:          user.Username,
+					AfterCreatedAt: last.CreatedAt,
+					AfterID:        last.ID,
+					Limit:          int32(n + 1),
+				}
+				store.EXPECT().
+					ListAccountsAfter(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts[n:], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccountsPage(t, recorder.Body, accounts[n:], "")
+			},
+		},
+		{
+			name:  "InvalidCursor",
+			query: map[string]string{"page_size": fmt.Sprint(n), "cursor": "invalid"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountsAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "PageIDAndCursor",
+			query: map[string]string{"page_id": "1", "page_size": fmt.Sprint(n), "cursor": nextCursor},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountsAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: map[string]string{"page_size": fmt.Sprint(n)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountsAfter(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/accounts", nil)
+			require.NoError(t, err)
+
+			q := request.URL.Query()
+			for key, value := range tc.query {
+				q.Add(key, value)
+			}
+			request.URL.RawQuery = q.Encode()
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListEntriesCursorAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	n := 5
+	entries := make([]db.Posting, n)
+	for i := range entries {
+		entries[i] = randomPosting(account)
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+	// there are exactly page_size entries, so there is no next page
+	arg := db.ListPostingsAfterParams{
+		AccountID: account.ID,
+		Limit:     int32(n + 1),
+	}
+	store.EXPECT().ListPostingsAfter(gomock.Any(), gomock.Eq(arg)).Times(1).Return(entries, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/accounts/%d/entries?page_size=%d", account.ID, n)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var page struct {
+		Items      []db.Posting `json:"items"`
+		NextCursor string       `json:"next_cursor"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &page)
+	require.NoError(t, err)
+	require.Equal(t, entries, page.Items)
+	require.Empty(t, page.NextCursor)
+}
+
+func TestListTransfersCursorAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+
+	n := 5
+	transfers := make([]db.Transfer, n+1)
+	for i := range transfers {
+		transfers[i] = randomTransfer(account1, account2)
+	}
+	last := transfers[n-1]
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+
+	// without filters, both directions are selected
+	arg := db.ListTransfersFilteredParams{
+		AccountID: account1.ID,
+		Outgoing:  true,
+		Incoming:  true,
+		Limit:     int32(n + 1),
+	}
+	store.EXPECT().ListTransfersFiltered(gomock.Any(), gomock.Eq(arg)).Times(1).Return(transfers, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/accounts/%d/transfers?page_size=%d", account1.ID, n)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var page struct {
+		Items      []db.Transfer `json:"items"`
+		NextCursor string        `json:"next_cursor"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &page)
+	require.NoError(t, err)
+	require.Equal(t, transfers[:n], page.Items)
+	require.Equal(t, encodeCursor(last.CreatedAt, last.ID), page.NextCursor)
+}
+
+func requireBodyMatchAccountsPage(t *testing.T, body *bytes.Buffer, accounts []db.Account, nextCursor string) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var page struct {
+		Items      []db.Account `json:"items"`
+		NextCursor string       `json:"next_cursor"`
+	}
+	err = json.Unmarshal(data, &page)
+	require.NoError(t, err)
+	require.Equal(t, nextCursor, page.NextCursor)
+
+	// the times are compared with Equal, since the location is lost in JSON
+	require.Len(t, page.Items, len(accounts))
+	for i := range accounts {
+		require.Equal(t, accounts[i].ID, page.Items[i].ID)
+		require.True(t, accounts[i].CreatedAt.Equal(page.Items[i].CreatedAt))
+	}
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/elmas23/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// The transfers above the approval threshold of their currency wait for a banker, see ApprovePendingTransferTx
+// All the routes of this file are only available to bankers, and a banker cannot review his own transfers
+
+// pendingTransferFilterRequest holds the optional status of the listed pending transfers
+// without it, the pending transfers of every status are listed
+type pendingTransferFilterRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected executed expired"`
+}
+
+// listPendingTransfers returns the pending transfers, oldest first, with the cursor pagination
+// example: http://localhost:8080/admin/pending_transfers?status=pending&page_size=10
+func (server *Server) listPendingTransfers(ctx *gin.Context) {
+	var req pageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var filter pendingTransferFilterRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// this list is new, so it only has the cursor pagination
+	if req.PageID != 0 {
+		err := errors.New("pending transfers use the cursor pagination, page_id is not supported")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	position, err := decodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// we get one more pending transfer than asked, this is how we know if there is a next page
+	arg := db.ListPendingTransfersParams{
+		Status:         sql.NullString{String: filter.Status, Valid: filter.Status != ""},
+		AfterCreatedAt: position.CreatedAt,
+		AfterID:        position.ID,
+		Limit:          req.PageSize + 1,
+	}
+
+	pendingTransfers, err := server.store.ListPendingTransfers(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := pageResponse{Items: pendingTransfers}
+	if len(pendingTransfers) > int(req.PageSize) {
+		pendingTransfers = pendingTransfers[:req.PageSize]
+		last := pendingTransfers[len(pendingTransfers)-1]
+		response = pageResponse{Items: pendingTransfers, NextCursor: encodeCursor(last.CreatedAt, last.ID)}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// getPendingTransferRequest holds the ID of the pending transfer, which is a URI parameter
+type getPendingTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// pendingTransferResponse is a pending transfer with its audit trail, oldest event first
+type pendingTransferResponse struct {
+	PendingTransfer db.PendingTransfer        `json:"pending_transfer"`
+	Events          []db.PendingTransferEvent `json:"events"`
+}
+
+// getPendingTransfer returns a single pending transfer, with who did what on it
+func (server *Server) getPendingTransfer(ctx *gin.Context) {
+	var req getPendingTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	pendingTransfer, err := server.store.GetPendingTransfer(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	events, err := server.store.ListPendingTransferEvents(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pendingTransferResponse{PendingTransfer: pendingTransfer, Events: events})
+}
+
+// approvePendingTransfer approves a pending transfer and executes it
+// If it cannot be executed, the error is the same as when a transfer is created, and it can be approved again later
+func (server *Server) approvePendingTransfer(ctx *gin.Context) {
+	var req getPendingTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ReviewPendingTransferTxParams{
+		PendingTransferID: req.ID,
+		Reviewer:          authPayload.Username,
+	}
+
+	result, err := server.store.ApprovePendingTransferTx(ctx, arg)
+	if err != nil {
+		if handled := handleReviewError(ctx, err); !handled {
+			ctx.JSON(transferErrorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// rejectPendingTransferRequest holds the reason of the rejection, it is saved in the audit trail
+type rejectPendingTransferRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// rejectPendingTransfer rejects a pending transfer, it will never be executed
+func (server *Server) rejectPendingTransfer(ctx *gin.Context) {
+	var uri getPendingTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req rejectPendingTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ReviewPendingTransferTxParams{
+		PendingTransferID: uri.ID,
+		Reviewer:          authPayload.Username,
+		Note:              req.Reason,
+	}
+
+	pendingTransfer, err := server.store.RejectPendingTransferTx(ctx, arg)
+	if err != nil {
+		if handled := handleReviewError(ctx, err); !handled {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pendingTransfer)
+}
+
+// handleReviewError writes the response of the errors that prevent a banker from reviewing a pending transfer
+// It returns false when the error is not one of them, so the caller writes the response itself
+func handleReviewError(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	case errors.Is(err, db.ErrSelfReview):
+		// the banker is allowed to review transfers, but not this one
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+	case errors.Is(err, db.ErrPendingTransferNotPending) || errors.Is(err, db.ErrPendingTransferExpired):
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,606 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/elmas23/simplebank/db/mock"
+	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/money"
+	"github.com/elmas23/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// randomPendingTransfer returns a transfer requested by a depositor, waiting for a banker
+func randomPendingTransfer(requestedBy string) db.PendingTransfer {
+	return db.PendingTransfer{
+		ID:            utils.GenerateRandomInt(1, 1000),
+		FromAccountID: utils.GenerateRandomInt(1, 1000),
+		ToAccountID:   utils.GenerateRandomInt(1, 1000),
+		Amount:        money.Amount(utils.GenerateRandomInt(10000, 100000)),
+		Currency:      "USD",
+		Status:        utils.PendingTransferStatus,
+		RequestedBy:   requestedBy,
+		ExpiresAt:     time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func TestListPendingTransfersAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	banker.Role = utils.BankerRole
+
+	n := 6
+	pendingTransfers := make([]db.PendingTransfer, n)
+	for i := range pendingTransfers {
+		pendingTransfers[i] = randomPendingTransfer(user.Username)
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "status=pending&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// one more than the page size is asked, to know if there is a next page
+				arg := db.ListPendingTransfersParams{
+					Status: sql.NullString{String: utils.PendingTransferStatus, Valid: true},
+					Limit:  6,
+				}
+				store.EXPECT().
+					ListPendingTransfers(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(pendingTransfers, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Items      []db.PendingTransfer `json:"items"`
+					NextCursor string               `json:"next_cursor"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, pendingTransfers[:5], response.Items)
+
+				last := pendingTransfers[4]
+				require.Equal(t, encodeCursor(last.CreatedAt, last.ID), response.NextCursor)
+			},
+		},
+		{
+			name:  "AllStatuses",
+			query: "page_size=10",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListPendingTransfersParams{Limit: 11}
+				store.EXPECT().
+					ListPendingTransfers(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(pendingTransfers, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "NotBanker",
+			query: "page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListPendingTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidStatus",
+			query: "status=waiting&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListPendingTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "PageIDNotSupported",
+			query: "page_id=1&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListPendingTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPendingTransfers(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.PendingTransfer{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/admin/pending_transfers?"+tc.query, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetPendingTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	banker.Role = utils.BankerRole
+
+	pendingTransfer := randomPendingTransfer(user.Username)
+	events := []db.PendingTransferEvent{
+		{
+			ID:                1,
+			PendingTransferID: pendingTransfer.ID,
+			Action:            utils.RequestedTransferAction,
+			Actor:             &user.Username,
+			Status:            utils.PendingTransferStatus,
+		},
+	}
+
+	testCases := []struct {
+		name              string
+		pendingTransferID int64
+		buildStubs        func(store *mockdb.MockStore)
+		checkResponse     func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:              "OK",
+			pendingTransferID: pendingTransfer.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pendingTransfer.ID)).Times(1).Return(pendingTransfer, nil)
+				store.EXPECT().ListPendingTransferEvents(gomock.Any(), gomock.Eq(pendingTransfer.ID)).Times(1).Return(events, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response pendingTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, pendingTransfer, response.PendingTransfer)
+				require.Equal(t, events, response.Events)
+			},
+		},
+		{
+			name:              "NotFound",
+			pendingTransferID: pendingTransfer.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Any()).Times(1).Return(db.PendingTransfer{}, sql.ErrNoRows)
+				store.EXPECT().ListPendingTransferEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:              "InvalidID",
+			pendingTransferID: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/pending_transfers/%d", tc.pendingTransferID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestApprovePendingTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	banker.Role = utils.BankerRole
+	otherBanker, _ := randomUser(t)
+	otherBanker.Role = utils.BankerRole
+
+	pendingTransfer := randomPendingTransfer(otherBanker.Username)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ReviewPendingTransferTxParams{
+					PendingTransferID: pendingTransfer.ID,
+					Reviewer:          banker.Username,
+				}
+
+				executed := pendingTransfer
+				executed.Status = utils.ExecutedTransferStatus
+				executed.ReviewedBy = &banker.Username
+				transferID := int64(1)
+				executed.TransferID = &transferID
+				result := db.TransferTxResult{
+					Transfer:        db.Transfer{ID: transferID, Amount: pendingTransfer.Amount},
+					PendingTransfer: &executed,
+				}
+				store.EXPECT().ApprovePendingTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var result struct {
+					PendingTransfer db.PendingTransfer `json:"pending_transfer"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+				require.Equal(t, utils.ExecutedTransferStatus, result.PendingTransfer.Status)
+				require.Equal(t, &banker.Username, result.PendingTransfer.ReviewedBy)
+			},
+		},
+		{
+			name: "NotBanker",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ApprovePendingTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "SelfReview",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				// this banker requested the transfer himself
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, otherBanker.Username, otherBanker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ApprovePendingTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{PendingTransfer: &pendingTransfer}, db.ErrSelfReview)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Expired",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ApprovePendingTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{PendingTransfer: &pendingTransfer}, db.ErrPendingTransferExpired)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// the transfer is approved, but it cannot be executed yet
+				approved := pendingTransfer
+				approved.Status = utils.ApprovedTransferStatus
+				store.EXPECT().
+					ApprovePendingTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{PendingTransfer: &approved}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ApprovePendingTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ApprovePendingTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/pending_transfers/%d/approve", pendingTransfer.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// a banker requests a large transfer from his own account, then tries to approve it himself
+// the transfer is requested and reviewed through the API, so this checks that both sides carry the username of the token
+func TestApprovePendingTransferAPIOwnRequest(t *testing.T) {
+	banker, _ := randomUser(t)
+	banker.Role = utils.BankerRole
+	user, _ := randomUser(t)
+
+	fromAccount := randomAccount(banker.Username)
+	fromAccount.Currency = "USD"
+	toAccount := randomAccount(user.Username)
+	toAccount.Currency = "USD"
+	amount := money.Amount(100000)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	// the transfer is above the approval threshold, so it is only saved as pending
+	var pendingTransfer db.PendingTransfer
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+	store.EXPECT().
+		TransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+			pendingTransfer = randomPendingTransfer(arg.RequestedBy)
+			pendingTransfer.FromAccountID = arg.FromAccountID
+			pendingTransfer.ToAccountID = arg.ToAccountID
+			pendingTransfer.Amount = arg.Amount.Amount
+			return db.TransferTxResult{PendingTransfer: &pendingTransfer}, nil
+		})
+
+	data, err := json.Marshal(gin.H{
+		"from_account_id": fromAccount.ID,
+		"to_account_id":   toAccount.ID,
+		"amount":          amount,
+		"currency":        "USD",
+	})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	require.Equal(t, banker.Username, pendingTransfer.RequestedBy)
+
+	// the store refuses a reviewer who is the requester, like ApprovePendingTransferTx does
+	store.EXPECT().
+		ApprovePendingTransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.ReviewPendingTransferTxParams) (db.TransferTxResult, error) {
+			require.Equal(t, pendingTransfer.ID, arg.PendingTransferID)
+			if arg.Reviewer == pendingTransfer.RequestedBy {
+				return db.TransferTxResult{PendingTransfer: &pendingTransfer}, db.ErrSelfReview
+			}
+			return db.TransferTxResult{}, nil
+		})
+
+	recorder = httptest.NewRecorder()
+	url := fmt.Sprintf("/admin/pending_transfers/%d/approve", pendingTransfer.ID)
+	request, err = http.NewRequest(http.MethodPost, url, nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestRejectPendingTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	banker.Role = utils.BankerRole
+
+	pendingTransfer := randomPendingTransfer(user.Username)
+	reason := "the receiver is not known"
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"reason": reason},
+			buildStubs: func(store *mockdb.MockStore) {
+				// the reason is saved in the audit trail
+				arg := db.ReviewPendingTransferTxParams{
+					PendingTransferID: pendingTransfer.ID,
+					Reviewer:          banker.Username,
+					Note:              reason,
+				}
+
+				rejected := pendingTransfer
+				rejected.Status = utils.RejectedTransferStatus
+				rejected.ReviewedBy = &banker.Username
+				store.EXPECT().RejectPendingTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(rejected, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rejected db.PendingTransfer
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rejected))
+				require.Equal(t, utils.RejectedTransferStatus, rejected.Status)
+			},
+		},
+		{
+			name: "MissingReason",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RejectPendingTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotPending",
+			body: gin.H{"reason": reason},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RejectPendingTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(pendingTransfer, db.ErrPendingTransferNotPending)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"reason": reason},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RejectPendingTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PendingTransfer{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/admin/pending_transfers/%d/reject", pendingTransfer.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
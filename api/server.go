@@ -1,16 +1,25 @@
 package api
 
 import (
+	"fmt"
+	"github.com/elmas23/simplebank/currency"
 	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/token"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 )
 
 // This is where we are going to implement our HTTP API server
 
 // Server will serve all the HTTP requests for our banking service
 type Server struct {
-	store  db.Store    // this will allow us to interact with the database when processing API requests from clients
-	router *gin.Engine // This router from gin wil help use send each API request to the correct handler for processing
+	config     utils.Config       // we keep the config to have access to values like the token duration
+	store      db.Store           // this will allow us to interact with the database when processing API requests from clients
+	tokenMaker token.Maker        // this is used to create and verify the access tokens of our users
+	currencies *currency.Registry // the currencies in which an account can be opened
+	router     *gin.Engine        // This router from gin wil help use send each API request to the correct handler for processing
 }
 
 // NewServer will create a new instance of Server
@@ -19,10 +28,37 @@ type Server struct {
 // NewServer : We pass store as an input parameters since that will be needed as defined per the struct
 // we don't pass the router as that can be built directly inside using gin
 // We remove the pointer since Store is no longer a struct pointer but an interface
-func NewServer(store db.Store) *Server {
-	server := &Server{store: store}
+// The config is needed to create the token maker, which can fail if the symmetric key is invalid
+// that's why we also return an error
+func NewServer(config utils.Config, store db.Store) (*Server, error) {
+	// We use PASETO tokens, but since the server only depends on the token.Maker interface
+	// we could switch to JWT by simply calling token.NewJWTMaker here instead
+	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	currencies, err := currency.NewRegistry(config.SupportedCurrencies)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load supported currencies: %w", err)
+	}
+
+	server := &Server{
+		config:     config,
+		store:      store,
+		tokenMaker: tokenMaker,
+		currencies: currencies,
+	}
 	router := gin.Default() // That's how we create a new router using gin
 
+	// We register our custom "currency" validator to the validator engine used by gin for the bindings
+	// The engine is shared by all the servers, so the last server created decides the supported currencies
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		if err := v.RegisterValidation("currency", newCurrencyValidator(currencies)); err != nil {
+			return nil, fmt.Errorf("cannot register currency validator: %w", err)
+		}
+	}
+
 	// Now let's add our first API route to create a new account
 	// This going to use the POST method
 	// the first argument is the path for our API
@@ -34,8 +70,17 @@ func NewServer(store db.Store) *Server {
 
 	// These methods need to be of the Server struct since they will need to access the store object
 	// So that it can save new accounts to the database
-	router.POST("/accounts", server.createAccount) // for creating an account
-	router.GET("/accounts/:id", server.getAccount) // for getting a specific account by the user ID
+	router.POST("/users", server.createUser)      // for registering a new customer
+	router.POST("/users/login", server.loginUser) // for getting an access token
+	router.POST("/users/logout", server.logoutUser)
+	router.POST("/tokens/renew_access", server.renewAccessToken) // for getting a new access token with a refresh token
+
+	// All the routes below require an access token
+	// so we group them and add the auth middleware to the group
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+
+	authRoutes.POST("/accounts", server.createAccount) // for creating an account
+	authRoutes.GET("/accounts/:id", server.getAccount) // for getting a specific account by the user ID
 	// the path contains a colon, that is to tell Gin that id is a URI parameter
 
 	// This router will be to retrieve a list of accounts using pagination
@@ -43,11 +88,70 @@ func NewServer(store db.Store) *Server {
 	// of the request example: http://localhost:8080/accounts?page_id=1&page_size=5
 	// page_in is the index number of the page we want to get, starting from page 1
 	// page_size, is the maximum number of records that can be returned in one page
-	router.GET("/accounts", server.listAccount)
+	// Without page_id, the client gets the first page and a next_cursor to get the following one
+	// example: http://localhost:8080/accounts?page_size=5&cursor=<next_cursor>
+	authRoutes.GET("/accounts", server.listAccount)
+
+	// These routes give the history of an account, with the same pagination as above
+	// the entries are the changes of the balance, and the transfers are the money sent or received
+	authRoutes.GET("/accounts/:id/entries", server.listEntries)
+	// the transfers can also be filtered with direction, since, until, min_amount and max_amount
+	// example: http://localhost:8080/accounts/1/transfers?page_size=10&direction=out&min_amount=500
+	authRoutes.GET("/accounts/:id/transfers", server.listTransfers)
+
+	// This router will be used to transfer money from one account to another
+	// Everything is done inside the TransferTx database transaction of our store
+	// a transfer above the approval threshold of its currency is only saved as pending, see the admin routes below
+	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.GET("/transfers/:id", server.getTransfer) // only the sender or the receiver can see a transfer
+
+	// These routes manage the standing orders of an account, the transfers that the bank makes on a schedule
+	// the scheduler of main.go makes the runs, and a standing order is never deleted, only cancelled
+	authRoutes.POST("/accounts/:id/standing-orders", server.createStandingOrder)
+	authRoutes.GET("/accounts/:id/standing-orders", server.listStandingOrders)
+	authRoutes.GET("/accounts/:id/standing-orders/:order_id", server.getStandingOrder) // with its last runs
+	authRoutes.PATCH("/accounts/:id/standing-orders/:order_id", server.updateStandingOrder)
+	authRoutes.DELETE("/accounts/:id/standing-orders/:order_id", server.cancelStandingOrder)
+
+	// These routes are the payments in two steps: the payer reserves the money with a hold,
+	// then the receiver captures it, in full or in parts, or releases it. A hold that is not used in time
+	// expires, the scheduler of main.go gives its money back
+	authRoutes.POST("/holds", server.createHold)
+	authRoutes.GET("/holds/:id", server.getHold) // with its captures, only the payer or the receiver can see a hold
+	authRoutes.POST("/holds/:id/capture", server.captureHold)
+	authRoutes.POST("/holds/:id/release", server.releaseHold)
+	authRoutes.GET("/accounts/:id/holds", server.listHolds) // the holds on the money of the account
+
+	// The admin routes are only available to the bankers
+	adminRoutes := router.Group("/admin").Use(authMiddleware(server.tokenMaker), roleMiddleware(utils.BankerRole))
+
+	// This router revokes all the sessions of a user, so he will have to log in again
+	adminRoutes.DELETE("/users/:username/sessions", server.revokeUserSessions)
+	// This router changes how far below zero the balance of an account can go
+	adminRoutes.PATCH("/accounts/:id/overdraft_limit", server.setOverdraftLimit)
+	// This router freezes, unfreezes or closes an account, accounts are never deleted
+	adminRoutes.PATCH("/accounts/:id/status", server.setAccountStatus)
+	// These routes bring money into an account from outside the bank, or take it out
+	// The other side is the settlement account of the currency, so the ledger stays balanced
+	// only a banker can make them, since the settlement account can go as far below zero as needed,
+	// and the banker is recorded on the journal entry
+	adminRoutes.POST("/accounts/:id/deposits", server.createDeposit)
+	adminRoutes.POST("/accounts/:id/withdrawals", server.createWithdrawal)
+	// These routes set the velocity limits of the transfers, for a single account or for all the accounts of a currency
+	// the approval threshold of the large transfers is also set with the limits of the currency
+	// a limit that is not sent is removed, so the account uses the one of its currency
+	adminRoutes.PUT("/accounts/:id/transfer_limits", server.setAccountTransferLimit)
+	adminRoutes.PUT("/currencies/:currency/transfer_limits", server.setCurrencyTransferLimit)
+	// These routes are the maker-checker workflow of the large transfers
+	// a transfer above the approval threshold of its currency waits here until another user approves or rejects it
+	adminRoutes.GET("/pending_transfers", server.listPendingTransfers)
+	adminRoutes.GET("/pending_transfers/:id", server.getPendingTransfer) // with its audit trail
+	adminRoutes.POST("/pending_transfers/:id/approve", server.approvePendingTransfer)
+	adminRoutes.POST("/pending_transfers/:id/reject", server.rejectPendingTransfer)
 
 	server.router = router // we set our server router to the router we just created using gin above
 
-	return server // and we return the server
+	return server, nil // and we return the server
 }
 
 // Start will run the HTTP sever on the input address to start listening for API requests
@@ -62,3 +166,8 @@ func (server *Server) Start(address string) error {
 func errorResponse(err error) gin.H {
 	return gin.H{"error": err.Error()}
 }
+
+// limitExceededResponse also sends which limit was exceeded, so the client can tell the user how much is left
+func limitExceededResponse(err *db.LimitExceededError) gin.H {
+	return gin.H{"error": err.Error(), "limit": err}
+}
@@ -0,0 +1,285 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/money"
+	"github.com/elmas23/simplebank/schedule"
+	"github.com/elmas23/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// A standing order is a transfer that the bank makes on a schedule, see ExecuteDueStandingOrderTx
+// It belongs to the account that sends the money, so all the routes of this file are under the account
+// and only its owner can use them
+
+// standingOrderRunsLimit is the number of runs returned with a standing order, most recent first
+const standingOrderRunsLimit = 20
+
+// createStandingOrderRequest holds the input of a new standing order
+// Like a transfer, the amount is in the minor unit of the currency of the sender's account
+// The first run is at start_at, then every interval_count days, weeks or months, until end_at if it is set
+// interval_count is optional, it is 1 when it is not sent
+type createStandingOrderRequest struct {
+	ToAccountID   int64      `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64      `json:"amount" binding:"required,gt=0"`
+	Currency      string     `json:"currency" binding:"required,currency"`
+	Frequency     string     `json:"frequency" binding:"required,oneof=daily weekly monthly"`
+	IntervalCount int32      `json:"interval_count" binding:"omitempty,min=1"`
+	StartAt       time.Time  `json:"start_at" binding:"required"`
+	EndAt         *time.Time `json:"end_at"`
+}
+
+// createStandingOrder creates a standing order from the account of the URI
+// The receiver can be any account, in any currency, the amount is converted at each run like a transfer
+func (server *Server) createStandingOrder(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req createStandingOrderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.IntervalCount == 0 {
+		req.IntervalCount = 1
+	}
+
+	plan := schedule.Schedule{
+		Frequency: req.Frequency,
+		Interval:  req.IntervalCount,
+		Start:     req.StartAt,
+		End:       req.EndAt,
+	}
+	if err := plan.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	// the runs are never made in the past, the first one would be made right away
+	if req.StartAt.Before(time.Now()) {
+		err := errors.New("start_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := server.ownedAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+	if fromAccount.Currency != req.Currency {
+		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", fromAccount.ID, fromAccount.Currency, req.Currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.ToAccountID == fromAccount.ID {
+		err := errors.New("a standing order cannot send money to its own account")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if _, valid = server.existingAccount(ctx, req.ToAccountID); !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.CreateStandingOrderParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        money.Amount(req.Amount),
+		Currency:      req.Currency,
+		Frequency:     req.Frequency,
+		IntervalCount: req.IntervalCount,
+		StartAt:       req.StartAt,
+		EndAt:         req.EndAt,
+		NextRunAt:     req.StartAt, // the first run of the schedule
+		CreatedBy:     authPayload.Username,
+	}
+
+	order, err := server.store.CreateStandingOrder(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+// listStandingOrders returns all the standing orders of an account, including the cancelled and completed ones
+// An account only has a few of them, so they are not paginated
+func (server *Server) listStandingOrders(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.ownedAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	orders, err := server.store.ListStandingOrders(ctx, uri.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, orders)
+}
+
+// getStandingOrderRequest holds the IDs of the account and of its standing order, which are URI parameters
+type getStandingOrderRequest struct {
+	AccountID int64 `uri:"id" binding:"required,min=1"`
+	OrderID   int64 `uri:"order_id" binding:"required,min=1"`
+}
+
+// standingOrderResponse is a standing order with its most recent runs, most recent first
+type standingOrderResponse struct {
+	StandingOrder db.StandingOrder      `json:"standing_order"`
+	Runs          []db.StandingOrderRun `json:"runs"`
+}
+
+// getStandingOrder returns a single standing order, with the outcome of its last runs
+func (server *Server) getStandingOrder(ctx *gin.Context) {
+	var uri getStandingOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	order, valid := server.ownedStandingOrder(ctx, uri)
+	if !valid {
+		return
+	}
+
+	runs, err := server.store.ListStandingOrderRuns(ctx, db.ListStandingOrderRunsParams{
+		StandingOrderID: order.ID,
+		Limit:           standingOrderRunsLimit,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, standingOrderResponse{StandingOrder: order, Runs: runs})
+}
+
+// updateStandingOrderRequest holds the changes of a standing order, a field that is not sent doesn't change
+// A standing order can be paused and resumed with its status, it is cancelled with the DELETE route
+// When it is resumed, the runs it missed while it was paused are skipped, they are not made all at once
+// Its schedule cannot change, a new standing order must be created instead
+type updateStandingOrderRequest struct {
+	Amount *int64     `json:"amount" binding:"omitempty,gt=0"`
+	EndAt  *time.Time `json:"end_at"`
+	Status string     `json:"status" binding:"omitempty,oneof=active paused"`
+}
+
+// updateStandingOrder changes the amount, the end or the status of a standing order
+// The next run uses the new amount, the runs already made don't change
+func (server *Server) updateStandingOrder(ctx *gin.Context) {
+	var uri getStandingOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateStandingOrderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	order, valid := server.ownedStandingOrder(ctx, uri)
+	if !valid {
+		return
+	}
+
+	// the start of a standing order never changes, so it can be checked before the update
+	if req.EndAt != nil && req.EndAt.Before(order.StartAt) {
+		err := fmt.Errorf("%w: end must be after start", schedule.ErrInvalidSchedule)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the fields that are not sent don't change
+	arg := db.UpdateStandingOrderTxParams{
+		ID:     order.ID,
+		EndAt:  req.EndAt,
+		Status: req.Status,
+	}
+	if req.Amount != nil {
+		amount := money.Amount(*req.Amount)
+		arg.Amount = &amount
+	}
+
+	server.saveStandingOrder(ctx, arg)
+}
+
+// cancelStandingOrder stops a standing order for good
+// It is not deleted, since its runs and their transfers still reference it
+func (server *Server) cancelStandingOrder(ctx *gin.Context) {
+	var uri getStandingOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	order, valid := server.ownedStandingOrder(ctx, uri)
+	if !valid {
+		return
+	}
+
+	server.saveStandingOrder(ctx, db.UpdateStandingOrderTxParams{
+		ID:     order.ID,
+		Status: utils.CancelledStandingOrderStatus,
+	})
+}
+
+// saveStandingOrder saves the changes of a standing order and writes it to the response
+// The store checks its status again once it is locked, so a change never reopens a standing order
+// that the scheduler has just completed: 422 is returned instead
+func (server *Server) saveStandingOrder(ctx *gin.Context, arg db.UpdateStandingOrderTxParams) {
+	order, err := server.store.UpdateStandingOrderTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrStandingOrderClosed) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+// ownedStandingOrder checks that the account of the URI belongs to the authenticated user, and that the standing order is one of his
+// Like ownedAccount, the error response is written directly to the context when it returns false
+func (server *Server) ownedStandingOrder(ctx *gin.Context, uri getStandingOrderRequest) (db.StandingOrder, bool) {
+	if _, valid := server.ownedAccount(ctx, uri.AccountID); !valid {
+		return db.StandingOrder{}, false
+	}
+
+	order, err := server.store.GetStandingOrder(ctx, uri.OrderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return order, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return order, false
+	}
+
+	// the standing order of another account is reported as missing, so its ID doesn't tell anything
+	if order.FromAccountID != uri.AccountID {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return order, false
+	}
+
+	return order, true
+}
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/elmas23/simplebank/fx"
+	"github.com/elmas23/simplebank/money"
+	"github.com/elmas23/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// transferRequest holds the input of a money transfer
+// The amount is in the minor unit of the currency, like cents, and must be strictly positive, so we use gt=0 in the binding
+// The currency is the one of the amount, so it must be the currency of the sender's account
+// The receiver's account can use another currency, in that case he gets the converted amount
+// The fee of the transfer, if any, is not part of the amount: the sender pays it on top, see TransferTx
+type transferRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+}
+
+func (server *Server) createTransfer(ctx *gin.Context) {
+	var req transferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	// a transfer to the same account would move nothing, but it would still be charged a fee and count in the limits
+	if req.FromAccountID == req.ToAccountID {
+		err := errors.New("cannot transfer money to the same account")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// If the client sent an idempotency key that was already used, we return the saved response
+	// this is checked first, so a replay never depends on the current state of the accounts
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	idempotency, err := idempotencyParams(ctx, authPayload.Username, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if server.replayIdempotentRequest(ctx, idempotency) {
+		return
+	}
+
+	// Before moving any money, we make sure that both accounts exist
+	// and that the currency of the sender matches the one of the request
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	// A user can only send money from his own accounts
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	// the receiver's currency is not checked, TransferTx converts the amount if it is different
+	_, valid = server.existingAccount(ctx, req.ToAccountID)
+	if !valid {
+		return
+	}
+
+	arg := db.TransferTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        money.New(money.Amount(req.Amount), req.Currency),
+		RequestedBy:   authPayload.Username,
+		Idempotency:   idempotency,
+	}
+
+	result, err := server.store.TransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyExists) {
+			server.handleIdempotencyKeyExists(ctx, idempotency)
+			return
+		}
+		ctx.JSON(transferErrorResponse(err))
+		return
+	}
+
+	// A large transfer is not executed yet, it waits for the approval of a banker
+	// 202 Accepted tells the client that the pending transfer was saved, but no money has moved
+	if result.PendingTransfer != nil {
+		ctx.JSON(http.StatusAccepted, result.PendingTransfer)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// transferErrorResponse returns the status code and the response of an error of TransferTx
+// It is also used when an approved pending transfer is executed, since it fails the same way
+func transferErrorResponse(err error) (int, gin.H) {
+	// The request is valid, but it cannot be processed with the current balance of the sender,
+	// or because one of the accounts is frozen or closed
+	if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrAccountNotActive) {
+		return http.StatusUnprocessableEntity, errorResponse(err)
+	}
+	// or because the amount cannot be converted into the currency of the receiver, or added to his balance
+	if errors.Is(err, fx.ErrRateNotFound) || errors.Is(err, fx.ErrAmountTooSmall) || errors.Is(err, fx.ErrAmountTooLarge) ||
+		errors.Is(err, money.ErrOverflow) {
+		return http.StatusUnprocessableEntity, errorResponse(err)
+	}
+	// or because the sender has reached one of his velocity limits
+	var limitErr *db.LimitExceededError
+	if errors.As(err, &limitErr) {
+		return http.StatusUnprocessableEntity, limitExceededResponse(limitErr)
+	}
+	return http.StatusInternalServerError, errorResponse(err)
+}
+
+// getTransferRequest holds the ID of the transfer, which is a URI parameter
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getTransfer returns a single transfer
+// A transfer can be seen by the owner of either of its accounts: the sender and the receiver
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		account, err := server.store.GetAccount(ctx, accountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if account.Owner == authPayload.Username {
+			ctx.JSON(http.StatusOK, transfer)
+			return
+		}
+	}
+
+	err = errors.New("transfer doesn't belong to the authenticated user")
+	ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+}
+
+// listTransfers returns the transfers sent or received by an account
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the ID of the account comes from the URI, and the pagination from the query
+	var req pageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var filter transferFilterRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := filter.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the filters use the index of the keyset queries, so they are not available with page_id
+	if req.PageID != 0 && filter.isSet() {
+		err := errors.New("transfer filters cannot be used with page_id, use the cursor pagination instead")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.ownedAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	// without a page_id, we use the cursor pagination
+	if req.PageID == 0 {
+		server.listTransfersAfter(ctx, uri.ID, req, filter)
+		return
+	}
+
+	// the same account is used for both sides, so we get the transfers where it is the sender or the receiver
+	arg := db.ListTransfersParams{
+		FromAccountID: uri.ID,
+		ToAccountID:   uri.ID,
+		Limit:         req.PageSize,
+		Offset:        req.offset(),
+	}
+
+	transfers, err := server.store.ListTransfers(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfers)
+}
+
+// transferFilterRequest holds the optional filters of the transfer history
+// direction is "out" for the transfers sent by the account, "in" for the ones it received, and "both" by default
+// the period starts at since (included) and ends at until (excluded), in the RFC 3339 format like 2023-01-31T00:00:00Z
+// min_amount and max_amount are both included, they are in the currency of the account:
+// the amount sent for an outgoing transfer, and the credited amount for an incoming one
+// we use pointers to know if the client sent a filter or not
+type transferFilterRequest struct {
+	Direction string     `form:"direction" binding:"omitempty,oneof=in out both"`
+	Since     *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until     *time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinAmount *int64     `form:"min_amount" binding:"omitempty,min=0"`
+	MaxAmount *int64     `form:"max_amount" binding:"omitempty,min=0"`
+}
+
+// validate checks that the ranges of the filter are not empty
+func (filter transferFilterRequest) validate() error {
+	if filter.Since != nil && filter.Until != nil && !filter.Until.After(*filter.Since) {
+		return errors.New("until must be after since")
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MaxAmount < *filter.MinAmount {
+		return errors.New("max_amount must be greater than or equal to min_amount")
+	}
+	return nil
+}
+
+// isSet tells if the client sent any filter
+func (filter transferFilterRequest) isSet() bool {
+	return filter.Direction != "" || filter.Since != nil || filter.Until != nil ||
+		filter.MinAmount != nil || filter.MaxAmount != nil
+}
+
+// listTransfersAfter returns a page of transfers with the cursor pagination
+func (server *Server) listTransfersAfter(ctx *gin.Context, accountID int64, req pageRequest, filter transferFilterRequest) {
+	position, err := decodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// we get one more transfer than asked, this is how we know if there is a next page
+	// the filters that the client didn't send are NULL, and a NULL filter selects every transfer
+	arg := db.ListTransfersFilteredParams{
+		AccountID:      accountID,
+		Outgoing:       filter.Direction != "in",
+		Incoming:       filter.Direction != "out",
+		AfterCreatedAt: position.CreatedAt,
+		AfterID:        position.ID,
+		Limit:          req.PageSize + 1,
+	}
+	if filter.Since != nil {
+		arg.Since = sql.NullTime{Time: *filter.Since, Valid: true}
+	}
+	if filter.Until != nil {
+		arg.Until = sql.NullTime{Time: *filter.Until, Valid: true}
+	}
+	if filter.MinAmount != nil {
+		arg.MinAmount = sql.NullInt64{Int64: *filter.MinAmount, Valid: true}
+	}
+	if filter.MaxAmount != nil {
+		arg.MaxAmount = sql.NullInt64{Int64: *filter.MaxAmount, Valid: true}
+	}
+
+	transfers, err := server.store.ListTransfersFiltered(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := pageResponse{Items: transfers}
+	if len(transfers) > int(req.PageSize) {
+		transfers = transfers[:req.PageSize]
+		last := transfers[len(transfers)-1]
+		response = pageResponse{Items: transfers, NextCursor: encodeCursor(last.CreatedAt, last.ID)}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// validAccount checks that the account with the given ID exists and that its currency
+// matches the input currency.
+// If the check fails, the error response is written directly to the context and false is returned,
+// so the caller only has to stop processing the request
+// The account is also returned so that the caller can check who owns it
+func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, valid := server.existingAccount(ctx, accountID)
+	if !valid {
+		return account, false
+	}
+
+	if account.Currency != currency {
+		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+// existingAccount checks that the account with the given ID exists, whatever its currency
+// Like validAccount, the error response is written directly to the context when it returns false
+func (server *Server) existingAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
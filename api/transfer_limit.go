@@ -0,0 +1,106 @@
+package api
+
+import (
+	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/elmas23/simplebank/money"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// transferLimitRequest holds the velocity limits of an account or of a currency, see TransferTx
+// The amounts are in the minor unit of the currency, like the amount of a transfer
+// A missing or null limit is not set: an account then uses the limit of its currency, and a currency has no limit
+// we use pointers so that 0 is accepted as a value, it blocks every transfer
+type transferLimitRequest struct {
+	MaxAmount      *int64 `json:"max_amount" binding:"omitempty,min=0"`
+	MaxDailyAmount *int64 `json:"max_daily_amount" binding:"omitempty,min=0"`
+	MaxDailyCount  *int64 `json:"max_daily_count" binding:"omitempty,min=0"`
+}
+
+// currencyTransferLimitRequest also holds the approval threshold, which is only set on a currency
+// a transfer above it is only executed once a banker approves it, see ApprovePendingTransferTx
+type currencyTransferLimitRequest struct {
+	transferLimitRequest
+	ApprovalThreshold *int64 `json:"approval_threshold" binding:"omitempty,min=0"`
+}
+
+// amountLimit converts an amount limit of the request into the type of the store
+func amountLimit(limit *int64) *money.Amount {
+	if limit == nil {
+		return nil
+	}
+	amount := money.Amount(*limit)
+	return &amount
+}
+
+// setAccountTransferLimit replaces the velocity limits of an account
+// This route is only available to bankers
+func (server *Server) setAccountTransferLimit(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req transferLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.existingAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	arg := db.SetAccountTransferLimitParams{
+		AccountID:      uri.ID,
+		MaxAmount:      amountLimit(req.MaxAmount),
+		MaxDailyAmount: amountLimit(req.MaxDailyAmount),
+		MaxDailyCount:  req.MaxDailyCount,
+	}
+
+	limit, err := server.store.SetAccountTransferLimit(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, limit)
+}
+
+// getCurrencyRequest holds the code of a currency, which is a URI parameter
+type getCurrencyRequest struct {
+	Currency string `uri:"currency" binding:"required,currency"`
+}
+
+// setCurrencyTransferLimit replaces the default velocity limits of the accounts of a currency, and its approval threshold
+// This route is only available to bankers
+func (server *Server) setCurrencyTransferLimit(ctx *gin.Context) {
+	var uri getCurrencyRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req currencyTransferLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.SetCurrencyTransferLimitParams{
+		Currency:          uri.Currency,
+		MaxAmount:         amountLimit(req.MaxAmount),
+		MaxDailyAmount:    amountLimit(req.MaxDailyAmount),
+		MaxDailyCount:     req.MaxDailyCount,
+		ApprovalThreshold: amountLimit(req.ApprovalThreshold),
+	}
+
+	limit, err := server.store.SetCurrencyTransferLimit(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, limit)
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"github.com/elmas23/simplebank/currency"
+	"github.com/go-playground/validator/v10"
+)
+
+// newCurrencyValidator returns the custom validator of the "currency" binding
+// A field is valid if it is the code of one of the currencies supported by the bank,
+// so the list of currencies doesn't have to be repeated in every request with oneof
+func newCurrencyValidator(currencies *currency.Registry) validator.Func {
+	return func(fieldLevel validator.FieldLevel) bool {
+		// the validator is called with the value of the field, which must be a string
+		if code, ok := fieldLevel.Field().Interface().(string); ok {
+			return currencies.IsSupported(code)
+		}
+		return false
+	}
+}
@@ -0,0 +1,100 @@
+package currency
+
+import (
+	"strconv"
+	"strings"
+)
+
+/*
+What are the minor units of a currency ?
+
+		The amounts are saved as integers in the smallest unit of their currency, to avoid the rounding errors of floats.
+		ISO 4217 gives the number of decimals of each currency, that is its minor units:
+
+				- 2 for USD, since 1 dollar = 100 cents, so 1234 is 12.34 USD
+				- 0 for JPY, since there is no smaller unit than the yen, so 1234 is 1234 JPY
+				- 3 for KWD, since 1 dinar = 1000 fils, so 1234 is 1.234 KWD
+*/
+
+// Currency is an ISO 4217 currency
+type Currency struct {
+	Code       string // the 3 letters code, like USD
+	MinorUnits int    // the number of decimals of an amount
+}
+
+// iso4217 holds the currencies that the bank could support, the supported ones are chosen in the config
+var iso4217 = map[string]Currency{
+	"AUD": {Code: "AUD", MinorUnits: 2},
+	"BHD": {Code: "BHD", MinorUnits: 3},
+	"BRL": {Code: "BRL", MinorUnits: 2},
+	"CAD": {Code: "CAD", MinorUnits: 2},
+	"CHF": {Code: "CHF", MinorUnits: 2},
+	"CLP": {Code: "CLP", MinorUnits: 0},
+	"CNY": {Code: "CNY", MinorUnits: 2},
+	"CZK": {Code: "CZK", MinorUnits: 2},
+	"DKK": {Code: "DKK", MinorUnits: 2},
+	"EUR": {Code: "EUR", MinorUnits: 2},
+	"GBP": {Code: "GBP", MinorUnits: 2},
+	"HKD": {Code: "HKD", MinorUnits: 2},
+	"HUF": {Code: "HUF", MinorUnits: 2},
+	"INR": {Code: "INR", MinorUnits: 2},
+	"ISK": {Code: "ISK", MinorUnits: 0},
+	"JOD": {Code: "JOD", MinorUnits: 3},
+	"JPY": {Code: "JPY", MinorUnits: 0},
+	"KRW": {Code: "KRW", MinorUnits: 0},
+	"KWD": {Code: "KWD", MinorUnits: 3},
+	"MXN": {Code: "MXN", MinorUnits: 2},
+	"NOK": {Code: "NOK", MinorUnits: 2},
+	"NZD": {Code: "NZD", MinorUnits: 2},
+	"OMR": {Code: "OMR", MinorUnits: 3},
+	"PLN": {Code: "PLN", MinorUnits: 2},
+	"SEK": {Code: "SEK", MinorUnits: 2},
+	"SGD": {Code: "SGD", MinorUnits: 2},
+	"TND": {Code: "TND", MinorUnits: 3},
+	"TRY": {Code: "TRY", MinorUnits: 2},
+	"USD": {Code: "USD", MinorUnits: 2},
+	"VND": {Code: "VND", MinorUnits: 0},
+	"ZAR": {Code: "ZAR", MinorUnits: 2},
+}
+
+// Lookup returns the ISO 4217 currency with the given code
+func Lookup(code string) (Currency, bool) {
+	currency, ok := iso4217[code]
+	return currency, ok
+}
+
+// MinorUnits returns the minor units of a currency
+// an unknown currency has no decimals, so its amounts are left as they are
+func MinorUnits(code string) int {
+	return iso4217[code].MinorUnits
+}
+
+// Format returns an amount in minor units as a decimal string, like "12.34" for 1234 USD
+func (currency Currency) Format(amount int64) string {
+	digits := strconv.FormatInt(amount, 10)
+	if currency.MinorUnits == 0 {
+		return digits
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		digits = digits[1:]
+	}
+
+	// we pad with zeros so that there is always one digit before the decimal point, like 0.05
+	if len(digits) <= currency.MinorUnits {
+		digits = strings.Repeat("0", currency.MinorUnits-len(digits)+1) + digits
+	}
+	point := len(digits) - currency.MinorUnits
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Format returns an amount in minor units of the currency with the given code as a decimal string
+func Format(amount int64, code string) string {
+	currency, ok := Lookup(code)
+	if !ok {
+		return strconv.FormatInt(amount, 10)
+	}
+	return currency.Format(amount)
+}
@@ -0,0 +1,61 @@
+package currency
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	currency, ok := Lookup("USD")
+	require.True(t, ok)
+	require.Equal(t, "USD", currency.Code)
+	require.Equal(t, 2, currency.MinorUnits)
+
+	require.Equal(t, 0, MinorUnits("JPY"))
+	require.Equal(t, 3, MinorUnits("KWD"))
+
+	_, ok = Lookup("XYZ")
+	require.False(t, ok)
+}
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		amount   int64
+		code     string
+		expected string
+	}{
+		{1234, "USD", "12.34"},
+		{5, "USD", "0.05"},
+		{0, "EUR", "0.00"},
+		{-1234, "USD", "-12.34"},
+		{-5, "USD", "-0.05"},
+		{1234, "JPY", "1234"},
+		{-1234, "JPY", "-1234"},
+		{1234, "KWD", "1.234"},
+		{1234, "XYZ", "1234"}, // an unknown currency is not formatted
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, Format(tc.amount, tc.code))
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry, err := NewRegistry([]string{"USD", " eur", "USD"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"USD", "EUR"}, registry.Codes())
+
+	require.True(t, registry.IsSupported("USD"))
+	require.True(t, registry.IsSupported("EUR"))
+	require.False(t, registry.IsSupported("JPY"))
+
+	currency, ok := registry.Get("EUR")
+	require.True(t, ok)
+	require.Equal(t, 2, currency.MinorUnits)
+
+	_, err = NewRegistry([]string{"USD", "XYZ"})
+	require.Error(t, err)
+
+	_, err = NewRegistry(nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package currency
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Registry holds the currencies supported by the bank
+// An account can only be opened, and money can only be transferred, in one of these currencies
+type Registry struct {
+	currencies map[string]Currency
+	codes      []string
+}
+
+// NewRegistry creates a new Registry with the given ISO 4217 codes
+// It returns an error if one of them is not an ISO 4217 currency, or if there are none
+func NewRegistry(codes []string) (*Registry, error) {
+	registry := &Registry{
+		currencies: make(map[string]Currency),
+	}
+
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		currency, ok := Lookup(code)
+		if !ok {
+			return nil, fmt.Errorf("unknown currency: %q", code)
+		}
+		if _, ok := registry.currencies[code]; ok {
+			continue
+		}
+		registry.currencies[code] = currency
+		registry.codes = append(registry.codes, code)
+	}
+
+	if len(registry.codes) == 0 {
+		return nil, fmt.Errorf("no supported currency")
+	}
+	return registry, nil
+}
+
+// Get returns the supported currency with the given code
+func (registry *Registry) Get(code string) (Currency, bool) {
+	currency, ok := registry.currencies[code]
+	return currency, ok
+}
+
+// IsSupported returns true if the currency with the given code is supported
+func (registry *Registry) IsSupported(code string) bool {
+	_, ok := registry.currencies[code]
+	return ok
+}
+
+// Codes returns the codes of the supported currencies, in the order of the config
+func (registry *Registry) Codes() []string {
+	return append([]string(nil), registry.codes...)
+}
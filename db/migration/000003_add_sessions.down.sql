@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "role";
+
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,6 @@
+-- An account can never be overdrawn
+-- Since the check is done by the database itself, no code path can drive a balance below zero
+-- The constraint is named so that the store can recognize it when it is violated
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_non_negative" CHECK ("balance" >= 0);
+
+COMMENT ON COLUMN "accounts"."balance" IS 'cannot be negative';
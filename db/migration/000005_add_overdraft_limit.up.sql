@@ -0,0 +1,14 @@
+-- Some accounts are allowed to go below zero, down to -overdraft_limit
+ALTER TABLE "accounts" ADD COLUMN "overdraft_limit" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "overdraft_limit_non_negative" CHECK ("overdraft_limit" >= 0);
+
+-- The balance is now checked against the overdraft limit of the same row
+-- Every update of an account locks its row, so the check always sees the latest balance and limit
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "balance_non_negative";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_within_overdraft_limit" CHECK ("balance" >= -"overdraft_limit");
+
+COMMENT ON COLUMN "accounts"."balance" IS 'cannot go below -overdraft_limit';
+
+COMMENT ON COLUMN "accounts"."overdraft_limit" IS 'cannot be negative';
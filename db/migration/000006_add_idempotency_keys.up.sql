@@ -0,0 +1,16 @@
+-- An idempotency key lets a client safely retry a request that changes money or accounts
+-- The key is chosen by the client, so it is only unique for a given user
+-- The response is written in the same transaction as the transfer or the account it belongs to
+CREATE TABLE "idempotency_keys" (
+                                    "username" varchar NOT NULL,
+                                    "key" varchar NOT NULL,
+                                    "request_hash" varchar NOT NULL,
+                                    "response_status" integer NOT NULL DEFAULT 0,
+                                    "response_body" bytea NOT NULL DEFAULT '',
+                                    "created_at" timestamptz NOT NULL DEFAULT (now()),
+                                    PRIMARY KEY ("username", "key")
+);
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+COMMENT ON COLUMN "idempotency_keys"."request_hash" IS 'a replay with a different request is rejected';
@@ -0,0 +1,6 @@
+-- These indexes support the amount filters of ListTransfersFiltered
+-- the period filters already use the (account, created_at, id) indexes of the keyset pagination
+-- there is one index for each direction, postgres combines them when both directions are selected
+CREATE INDEX "transfers_from_account_id_amount_idx" ON "transfers" ("from_account_id", "amount");
+
+CREATE INDEX "transfers_to_account_id_amount_idx" ON "transfers" ("to_account_id", "amount");
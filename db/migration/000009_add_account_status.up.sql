@@ -0,0 +1,17 @@
+-- An account is never deleted, since the entries and the transfers of the ledger reference it
+-- Instead, its status tells what can be done with it:
+--   active: the account can send and receive money
+--   frozen: no money can move, until a banker unfreezes the account
+--   closed: no money can ever move again, and it can only be closed with a zero balance
+ALTER TABLE "accounts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "account_status_valid" CHECK ("status" IN ('active', 'frozen', 'closed'));
+
+ALTER TABLE "accounts" ADD CONSTRAINT "closed_account_zero_balance" CHECK ("status" <> 'closed' OR "balance" = 0);
+
+-- a user can open a new account in the currency of an account that he closed
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_key";
+
+CREATE UNIQUE INDEX "owner_currency_key" ON "accounts" ("owner", "currency") WHERE "status" <> 'closed';
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen or closed';
@@ -0,0 +1,9 @@
+ALTER TABLE IF EXISTS "transfers" DROP CONSTRAINT IF EXISTS "exchange_rate_positive";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "credited_amount";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "exchange_rate";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "to_currency";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "from_currency";
@@ -0,0 +1,33 @@
+-- A transfer can now be made between accounts of different currencies
+-- amount is still what the sender pays, in from_currency
+-- credited_amount is what the receiver gets, in to_currency, once converted with exchange_rate
+-- everything is saved with the transfer, so it can be audited even after the rates have changed
+ALTER TABLE "transfers" ADD COLUMN "from_currency" varchar;
+
+ALTER TABLE "transfers" ADD COLUMN "to_currency" varchar;
+
+ALTER TABLE "transfers" ADD COLUMN "exchange_rate" numeric(20, 10) NOT NULL DEFAULT 1;
+
+ALTER TABLE "transfers" ADD COLUMN "credited_amount" bigint;
+
+-- the existing transfers were all made between accounts of the same currency
+UPDATE "transfers" SET
+    "from_currency" = "accounts"."currency",
+    "to_currency" = "accounts"."currency",
+    "credited_amount" = "transfers"."amount"
+FROM "accounts"
+WHERE "accounts"."id" = "transfers"."from_account_id";
+
+ALTER TABLE "transfers" ALTER COLUMN "from_currency" SET NOT NULL;
+
+ALTER TABLE "transfers" ALTER COLUMN "to_currency" SET NOT NULL;
+
+ALTER TABLE "transfers" ALTER COLUMN "credited_amount" SET NOT NULL;
+
+ALTER TABLE "transfers" ALTER COLUMN "exchange_rate" DROP DEFAULT;
+
+ALTER TABLE "transfers" ADD CONSTRAINT "exchange_rate_positive" CHECK ("exchange_rate" > 0);
+
+COMMENT ON COLUMN "transfers"."exchange_rate" IS 'credited_amount = amount * exchange_rate, rounded down';
+
+COMMENT ON COLUMN "transfers"."credited_amount" IS 'in to_currency, cannot be negative';
@@ -0,0 +1,17 @@
+-- Every entry written by a transfer now references it, so the ledger can be reconciled:
+-- a transfer must have exactly one entry that debits the sender and one that credits the receiver
+-- The column is nullable, since an entry doesn't have to come from a transfer
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX "entries_transfer_id_idx" ON "entries" ("transfer_id");
+
+-- The existing entries are linked to their transfer: TransferTx created the transfer and its entries
+-- in the same transaction, and now() is the start time of the transaction, so they have the same created_at
+UPDATE "entries" SET "transfer_id" = "transfers"."id"
+FROM "transfers"
+WHERE "entries"."transfer_id" IS NULL
+  AND "entries"."created_at" = "transfers"."created_at"
+  AND (("entries"."account_id" = "transfers"."from_account_id" AND "entries"."amount" = -"transfers"."amount")
+    OR ("entries"."account_id" = "transfers"."to_account_id" AND "entries"."amount" = "transfers"."credited_amount"));
@@ -0,0 +1,47 @@
+DROP TRIGGER IF EXISTS "journal_entry_balanced" ON "postings";
+
+DROP FUNCTION IF EXISTS "check_journal_entry_balanced"();
+
+ALTER TABLE IF EXISTS "postings" DROP CONSTRAINT IF EXISTS "postings_account_id_currency_fkey";
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "accounts_id_currency_key";
+
+-- the postings of the system accounts didn't exist before the journal
+DELETE FROM "postings"
+WHERE "account_id" IN (
+    SELECT "accounts"."id" FROM "accounts"
+    JOIN "users" ON "users"."username" = "accounts"."owner"
+    WHERE "users"."role" = 'system'
+);
+
+DELETE FROM "accounts" WHERE "owner" IN (SELECT "username" FROM "users" WHERE "role" = 'system');
+
+DELETE FROM "users" WHERE "role" = 'system';
+
+ALTER TABLE "postings" ADD CONSTRAINT "entries_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "postings" ADD COLUMN "transfer_id" bigint;
+
+UPDATE "postings" SET "transfer_id" = "journal_entries"."transfer_id"
+FROM "journal_entries"
+WHERE "journal_entries"."id" = "postings"."journal_entry_id";
+
+ALTER TABLE "postings" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "postings" DROP COLUMN "journal_entry_id";
+
+ALTER TABLE "postings" DROP COLUMN "currency";
+
+ALTER INDEX "postings_account_id_created_at_id_idx" RENAME TO "entries_account_id_created_at_id_idx";
+
+ALTER INDEX "postings_account_id_idx" RENAME TO "entries_account_id_idx";
+
+ALTER INDEX "postings_pkey" RENAME TO "entries_pkey";
+
+ALTER SEQUENCE "postings_id_seq" RENAME TO "entries_id_seq";
+
+ALTER TABLE "postings" RENAME TO "entries";
+
+CREATE INDEX "entries_transfer_id_idx" ON "entries" ("transfer_id");
+
+DROP TABLE IF EXISTS "journal_entries";
@@ -0,0 +1,164 @@
+-- The ledger is now a double-entry journal:
+--   a journal entry is one business event, like a transfer
+--   a posting adds an amount to the balance of one account, it always belongs to a journal entry
+-- The postings of a journal entry must net to zero in each currency, so money is never created or lost
+-- This is checked by the database when the transaction commits, see the "journal_entry_balanced" trigger below
+
+-- The system accounts belong to the bank instead of a customer, they take the other side of the postings
+-- that don't move money between two customers: fees, foreign exchange, or money that cannot be explained yet
+-- Each one is owned by a system user, and there is one account per currency, created the first time it is needed
+-- These users cannot log in, since their password is not a bcrypt hash,
+-- and their usernames cannot be registered, since they are not alphanumeric
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email", "role") VALUES
+    ('system_fees', '', 'Fees', 'fees@system.simplebank.local', 'system'),
+    ('system_fx', '', 'Foreign exchange', 'fx@system.simplebank.local', 'system'),
+    ('system_suspense', '', 'Suspense', 'suspense@system.simplebank.local', 'system');
+
+CREATE TABLE "journal_entries" (
+                                   "id" bigserial PRIMARY KEY,
+                                   "kind" varchar NOT NULL,
+                                   "transfer_id" bigint UNIQUE,
+                                   "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "journal_entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+COMMENT ON COLUMN "journal_entries"."kind" IS 'the business event: transfer, migration...';
+
+COMMENT ON COLUMN "journal_entries"."transfer_id" IS 'a transfer has exactly one journal entry';
+
+-- The entries become the postings of the journal
+ALTER TABLE "entries" RENAME TO "postings";
+
+ALTER SEQUENCE "entries_id_seq" RENAME TO "postings_id_seq";
+
+ALTER INDEX "entries_pkey" RENAME TO "postings_pkey";
+
+ALTER INDEX "entries_account_id_idx" RENAME TO "postings_account_id_idx";
+
+ALTER INDEX "entries_account_id_created_at_id_idx" RENAME TO "postings_account_id_created_at_id_idx";
+
+ALTER TABLE "postings" ADD COLUMN "journal_entry_id" bigint;
+
+-- the currency is the one of the account, it is copied so that the postings can be summed by currency
+ALTER TABLE "postings" ADD COLUMN "currency" varchar;
+
+UPDATE "postings" SET "currency" = "accounts"."currency"
+FROM "accounts"
+WHERE "accounts"."id" = "postings"."account_id";
+
+-- Each existing transfer gets its journal entry, with the two entries that reference it
+INSERT INTO "journal_entries" ("kind", "transfer_id", "created_at")
+SELECT 'transfer', "id", "created_at" FROM "transfers";
+
+UPDATE "postings" SET "journal_entry_id" = "journal_entries"."id"
+FROM "journal_entries"
+WHERE "journal_entries"."transfer_id" = "postings"."transfer_id";
+
+-- A transfer between two currencies debits one currency and credits another
+-- so the foreign exchange account takes the other side in each currency
+INSERT INTO "accounts" ("owner", "balance", "currency", "overdraft_limit")
+SELECT 'system_fx', 0, "currency", 9223372036854775807
+FROM (
+    SELECT "from_currency" AS "currency" FROM "transfers" WHERE "from_currency" <> "to_currency"
+    UNION
+    SELECT "to_currency" FROM "transfers" WHERE "from_currency" <> "to_currency"
+) AS "currencies";
+
+INSERT INTO "postings" ("journal_entry_id", "account_id", "currency", "amount", "created_at")
+SELECT "journal_entries"."id", "fx"."id", "transfers"."from_currency", "transfers"."amount", "transfers"."created_at"
+FROM "transfers"
+JOIN "journal_entries" ON "journal_entries"."transfer_id" = "transfers"."id"
+JOIN "accounts" AS "fx" ON "fx"."owner" = 'system_fx' AND "fx"."currency" = "transfers"."from_currency"
+WHERE "transfers"."from_currency" <> "transfers"."to_currency"
+UNION ALL
+SELECT "journal_entries"."id", "fx"."id", "transfers"."to_currency", -"transfers"."credited_amount", "transfers"."created_at"
+FROM "transfers"
+JOIN "journal_entries" ON "journal_entries"."transfer_id" = "transfers"."id"
+JOIN "accounts" AS "fx" ON "fx"."owner" = 'system_fx' AND "fx"."currency" = "transfers"."to_currency"
+WHERE "transfers"."from_currency" <> "transfers"."to_currency";
+
+-- The entries that don't come from a transfer are grouped in a single migration journal entry
+WITH "journal" AS (
+    INSERT INTO "journal_entries" ("kind")
+    SELECT 'migration' WHERE EXISTS (SELECT 1 FROM "postings" WHERE "journal_entry_id" IS NULL)
+    RETURNING "id"
+)
+UPDATE "postings" SET "journal_entry_id" = "journal"."id"
+FROM "journal"
+WHERE "postings"."journal_entry_id" IS NULL;
+
+-- Whatever still doesn't net to zero, like a transfer with a missing entry, is balanced with the suspense account
+-- so the history is kept as it is, and the difference can be investigated later
+INSERT INTO "accounts" ("owner", "balance", "currency", "overdraft_limit")
+SELECT DISTINCT 'system_suspense', 0, "currency", 9223372036854775807
+FROM (
+    SELECT "currency" FROM "postings"
+    GROUP BY "journal_entry_id", "currency"
+    HAVING SUM("amount") <> 0
+) AS "currencies";
+
+INSERT INTO "postings" ("journal_entry_id", "account_id", "currency", "amount")
+SELECT "postings"."journal_entry_id", "suspense"."id", "postings"."currency", -SUM("postings"."amount")
+FROM "postings"
+JOIN "accounts" AS "suspense" ON "suspense"."owner" = 'system_suspense' AND "suspense"."currency" = "postings"."currency"
+GROUP BY "postings"."journal_entry_id", "suspense"."id", "postings"."currency"
+HAVING SUM("postings"."amount") <> 0;
+
+-- the balance of a system account is the sum of its postings
+UPDATE "accounts" SET "balance" = "totals"."total"
+FROM (SELECT "account_id", SUM("amount") AS "total" FROM "postings" GROUP BY "account_id") AS "totals"
+WHERE "accounts"."id" = "totals"."account_id"
+  AND "accounts"."owner" IN ('system_fx', 'system_suspense');
+
+ALTER TABLE "postings" ALTER COLUMN "journal_entry_id" SET NOT NULL;
+
+ALTER TABLE "postings" ALTER COLUMN "currency" SET NOT NULL;
+
+ALTER TABLE "postings" ADD FOREIGN KEY ("journal_entry_id") REFERENCES "journal_entries" ("id");
+
+CREATE INDEX "postings_journal_entry_id_idx" ON "postings" ("journal_entry_id");
+
+-- the journal entry replaces the link between an entry and its transfer
+ALTER TABLE "postings" DROP COLUMN "transfer_id";
+
+-- The currency of a posting must be the currency of its account
+-- the foreign key references both columns, so a posting can never be in another currency
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_id_currency_key" UNIQUE ("id", "currency");
+
+ALTER TABLE "postings" DROP CONSTRAINT "entries_account_id_fkey";
+
+ALTER TABLE "postings" ADD CONSTRAINT "postings_account_id_currency_fkey"
+    FOREIGN KEY ("account_id", "currency") REFERENCES "accounts" ("id", "currency");
+
+-- A CHECK constraint can only see one row, so the postings of a journal entry are checked by a trigger
+-- It is a constraint trigger that is deferred until the commit, so the postings can be inserted one by one
+-- and only the final state of the journal entry is checked
+CREATE FUNCTION "check_journal_entry_balanced"() RETURNS trigger AS $$
+DECLARE
+    journal_id bigint;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        journal_id := OLD."journal_entry_id";
+    ELSE
+        journal_id := NEW."journal_entry_id";
+    END IF;
+
+    IF EXISTS (
+        SELECT 1 FROM "postings"
+        WHERE "journal_entry_id" = journal_id
+        GROUP BY "currency"
+        HAVING SUM("amount") <> 0
+    ) THEN
+        RAISE EXCEPTION 'postings of journal entry % do not net to zero', journal_id
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'journal_entry_balanced';
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER "journal_entry_balanced"
+    AFTER INSERT OR UPDATE OR DELETE ON "postings"
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION "check_journal_entry_balanced"();
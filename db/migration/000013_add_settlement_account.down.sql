@@ -0,0 +1,27 @@
+-- The deposits and withdrawals are kept, their other side is moved to the suspense account
+-- so the journal entries still net to zero and the balances of the customers don't change
+INSERT INTO "accounts" ("owner", "balance", "currency", "overdraft_limit")
+SELECT 'system_suspense', 0, "settlement"."currency", 9223372036854775807
+FROM "accounts" AS "settlement"
+WHERE "settlement"."owner" = 'system_settlement'
+  AND NOT EXISTS (
+    SELECT 1 FROM "accounts" AS "suspense"
+    WHERE "suspense"."owner" = 'system_suspense' AND "suspense"."currency" = "settlement"."currency"
+);
+
+UPDATE "postings" SET "account_id" = "suspense"."id"
+FROM "accounts" AS "settlement", "accounts" AS "suspense"
+WHERE "postings"."account_id" = "settlement"."id"
+  AND "settlement"."owner" = 'system_settlement'
+  AND "suspense"."owner" = 'system_suspense'
+  AND "suspense"."currency" = "settlement"."currency";
+
+UPDATE "accounts" SET "balance" = "accounts"."balance" + "settlement"."balance"
+FROM "accounts" AS "settlement"
+WHERE "accounts"."owner" = 'system_suspense'
+  AND "settlement"."owner" = 'system_settlement'
+  AND "settlement"."currency" = "accounts"."currency";
+
+DELETE FROM "accounts" WHERE "owner" = 'system_settlement';
+
+DELETE FROM "users" WHERE "username" = 'system_settlement';
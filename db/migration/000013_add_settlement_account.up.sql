@@ -0,0 +1,6 @@
+-- The settlement account is the other side of the deposits and withdrawals
+-- it stands for the money outside the bank, so a deposit never creates money from nothing
+-- Like the other system users, it cannot log in, and there is one account per currency, created the first time it is needed
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email", "role") VALUES
+    ('system_settlement', '', 'Settlement', 'settlement@system.simplebank.local', 'system')
+ON CONFLICT ("username") DO NOTHING;
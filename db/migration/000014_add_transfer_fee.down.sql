@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "transfers" DROP CONSTRAINT IF EXISTS "fee_not_negative";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "fee";
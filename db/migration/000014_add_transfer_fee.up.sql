@@ -0,0 +1,8 @@
+-- A transfer can now have a fee, paid by the sender on top of the amount and credited to the fees system account
+-- it is saved with the transfer, so it can be audited even after the fee schedule has changed
+-- the existing transfers didn't have any fee
+ALTER TABLE "transfers" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "transfers" ADD CONSTRAINT "fee_not_negative" CHECK ("fee" >= 0);
+
+COMMENT ON COLUMN "transfers"."fee" IS 'in from_currency, the sender is debited with amount + fee';
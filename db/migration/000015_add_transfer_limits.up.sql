@@ -0,0 +1,30 @@
+-- The velocity limits cap how much money can leave an account by transfer
+--   max_amount: the amount of a single transfer
+--   max_daily_amount: the total of the transfers sent in the last 24 hours, this one included
+--   max_daily_count: the number of transfers sent in the last 24 hours, this one included
+-- A NULL limit means that there is no limit
+-- The limits of a currency are the defaults of its accounts, an account can override each of them
+-- The amounts are in the minor unit of the currency, like the amounts of the transfers
+CREATE TABLE "currency_transfer_limits" (
+                                            "currency" varchar PRIMARY KEY,
+                                            "max_amount" bigint,
+                                            "max_daily_amount" bigint,
+                                            "max_daily_count" bigint,
+                                            "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "account_transfer_limits" (
+                                           "account_id" bigint PRIMARY KEY,
+                                           "max_amount" bigint,
+                                           "max_daily_amount" bigint,
+                                           "max_daily_count" bigint,
+                                           "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "account_transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "currency_transfer_limits" ADD CONSTRAINT "currency_transfer_limits_not_negative"
+    CHECK ("max_amount" >= 0 AND "max_daily_amount" >= 0 AND "max_daily_count" >= 0);
+
+ALTER TABLE "account_transfer_limits" ADD CONSTRAINT "account_transfer_limits_not_negative"
+    CHECK ("max_amount" >= 0 AND "max_daily_amount" >= 0 AND "max_daily_count" >= 0);
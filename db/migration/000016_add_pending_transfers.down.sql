@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "pending_transfer_events";
+
+DROP TABLE IF EXISTS "pending_transfers";
+
+ALTER TABLE "currency_transfer_limits" DROP COLUMN IF EXISTS "approval_threshold";
@@ -0,0 +1,78 @@
+-- A transfer above the approval threshold of its currency is not executed right away
+-- It is saved as a pending transfer, and a banker other than the one who requested it must approve it:
+--   pending: waiting for a banker
+--   approved: a banker approved it, it stays approved if it cannot be executed yet, like without enough money
+--   rejected: a banker rejected it, nothing will ever be moved
+--   executed: the transfer was made, transfer_id is the transfer of the ledger
+--   expired: nobody approved or executed it before expires_at
+-- A NULL threshold means that no transfer of the currency needs an approval
+ALTER TABLE "currency_transfer_limits" ADD COLUMN "approval_threshold" bigint;
+
+ALTER TABLE "currency_transfer_limits" ADD CONSTRAINT "approval_threshold_not_negative" CHECK ("approval_threshold" >= 0);
+
+CREATE TABLE "pending_transfers" (
+                                     "id" bigserial PRIMARY KEY,
+                                     "from_account_id" bigint NOT NULL,
+                                     "to_account_id" bigint NOT NULL,
+                                     "amount" bigint NOT NULL,
+                                     "currency" varchar NOT NULL,
+                                     "status" varchar NOT NULL DEFAULT 'pending',
+                                     "requested_by" varchar NOT NULL,
+                                     "reviewed_by" varchar,
+                                     "reviewed_at" timestamptz,
+                                     "transfer_id" bigint UNIQUE,
+                                     "expires_at" timestamptz NOT NULL,
+                                     "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("requested_by") REFERENCES "users" ("username");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("reviewed_by") REFERENCES "users" ("username");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "pending_transfers" ADD CONSTRAINT "pending_transfer_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "pending_transfers" ADD CONSTRAINT "pending_transfer_status_valid"
+    CHECK ("status" IN ('pending', 'approved', 'rejected', 'executed', 'expired'));
+
+-- this is the maker-checker rule: nobody can approve or reject his own transfer
+ALTER TABLE "pending_transfers" ADD CONSTRAINT "pending_transfer_reviewer_not_requester" CHECK ("reviewed_by" <> "requested_by");
+
+ALTER TABLE "pending_transfers" ADD CONSTRAINT "executed_pending_transfer_has_transfer"
+    CHECK (("status" = 'executed') = ("transfer_id" IS NOT NULL));
+
+-- the bankers list the pending transfers by status, with the keyset pagination
+CREATE INDEX ON "pending_transfers" ("status", "created_at", "id");
+
+COMMENT ON COLUMN "pending_transfers"."amount" IS 'in the currency of the sender, without the fee';
+
+COMMENT ON COLUMN "pending_transfers"."status" IS 'pending, approved, rejected, executed or expired';
+
+-- The audit trail of the pending transfers, a row is only ever inserted
+-- action is what happened: requested, approved, rejected, executed, execution_failed or expired
+-- actor is the user who did it, it is NULL for an expiry since nobody did it
+-- status is the status of the pending transfer after the action
+CREATE TABLE "pending_transfer_events" (
+                                           "id" bigserial PRIMARY KEY,
+                                           "pending_transfer_id" bigint NOT NULL,
+                                           "action" varchar NOT NULL,
+                                           "actor" varchar,
+                                           "status" varchar NOT NULL,
+                                           "note" varchar NOT NULL DEFAULT '',
+                                           "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "pending_transfer_events" ADD FOREIGN KEY ("pending_transfer_id") REFERENCES "pending_transfers" ("id");
+
+ALTER TABLE "pending_transfer_events" ADD FOREIGN KEY ("actor") REFERENCES "users" ("username");
+
+CREATE INDEX ON "pending_transfer_events" ("pending_transfer_id", "id");
+
+COMMENT ON COLUMN "pending_transfer_events"."actor" IS 'NULL when nobody did it, like an expiry';
+
+COMMENT ON COLUMN "pending_transfer_events"."note" IS 'the reason of a rejection, or the error of a failed execution';
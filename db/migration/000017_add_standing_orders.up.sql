@@ -0,0 +1,87 @@
+-- A standing order is a transfer that is made again and again on a schedule, like the rent on the 1st of every month
+-- The schedule runs every interval_count days, weeks or months from start_at, and stops after end_at if it is set
+--   next_run_at: the date of the next run, it is the run number run_count of the schedule
+--   failed_attempts and retry_at: when the last attempt of the next run failed, it is tried again at retry_at
+-- The status of a standing order is:
+--   active: it runs on its schedule
+--   paused: it doesn't run until it is active again, the runs it missed are then skipped (see 000020)
+--   cancelled: it will never run again, it is kept since its runs reference it
+--   completed: its last run was made
+CREATE TABLE "standing_orders" (
+                                   "id" bigserial PRIMARY KEY,
+                                   "from_account_id" bigint NOT NULL,
+                                   "to_account_id" bigint NOT NULL,
+                                   "amount" bigint NOT NULL,
+                                   "currency" varchar NOT NULL,
+                                   "frequency" varchar NOT NULL,
+                                   "interval_count" integer NOT NULL DEFAULT 1,
+                                   "start_at" timestamptz NOT NULL,
+                                   "end_at" timestamptz,
+                                   "next_run_at" timestamptz NOT NULL,
+                                   "run_count" bigint NOT NULL DEFAULT 0,
+                                   "failed_attempts" integer NOT NULL DEFAULT 0,
+                                   "retry_at" timestamptz,
+                                   "status" varchar NOT NULL DEFAULT 'active',
+                                   "created_by" varchar NOT NULL,
+                                   "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
+
+ALTER TABLE "standing_orders" ADD CONSTRAINT "standing_order_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "standing_orders" ADD CONSTRAINT "standing_order_frequency_valid" CHECK ("frequency" IN ('daily', 'weekly', 'monthly'));
+
+ALTER TABLE "standing_orders" ADD CONSTRAINT "standing_order_interval_positive" CHECK ("interval_count" > 0);
+
+ALTER TABLE "standing_orders" ADD CONSTRAINT "standing_order_status_valid"
+    CHECK ("status" IN ('active', 'paused', 'cancelled', 'completed'));
+
+CREATE INDEX ON "standing_orders" ("from_account_id");
+
+-- the scheduler looks for the active standing orders that are due, this index only has them
+CREATE INDEX ON "standing_orders" ((COALESCE("retry_at", "next_run_at"))) WHERE "status" = 'active';
+
+COMMENT ON COLUMN "standing_orders"."amount" IS 'in the currency of the sender, without the fee';
+
+COMMENT ON COLUMN "standing_orders"."status" IS 'active, paused, cancelled or completed';
+
+-- Each attempt of a run of a standing order, a row is only ever inserted
+-- The outcome of the attempt is:
+--   succeeded: the transfer was made, transfer_id is the transfer of the ledger
+--   pending_approval: the amount is above the approval threshold, pending_transfer_id waits for a banker
+--   retrying: the transfer failed, it is tried again at retry_at
+--   failed: the transfer failed max_attempts times, this run is skipped
+CREATE TABLE "standing_order_runs" (
+                                       "id" bigserial PRIMARY KEY,
+                                       "standing_order_id" bigint NOT NULL,
+                                       "scheduled_for" timestamptz NOT NULL,
+                                       "attempt" integer NOT NULL,
+                                       "max_attempts" integer NOT NULL,
+                                       "outcome" varchar NOT NULL,
+                                       "transfer_id" bigint,
+                                       "pending_transfer_id" bigint,
+                                       "error" varchar NOT NULL DEFAULT '',
+                                       "retry_at" timestamptz,
+                                       "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "standing_order_runs" ADD FOREIGN KEY ("standing_order_id") REFERENCES "standing_orders" ("id");
+
+ALTER TABLE "standing_order_runs" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "standing_order_runs" ADD FOREIGN KEY ("pending_transfer_id") REFERENCES "pending_transfers" ("id");
+
+ALTER TABLE "standing_order_runs" ADD CONSTRAINT "standing_order_run_outcome_valid"
+    CHECK ("outcome" IN ('succeeded', 'pending_approval', 'retrying', 'failed'));
+
+-- an attempt of a run is only recorded once, even if several servers run the scheduler
+CREATE UNIQUE INDEX ON "standing_order_runs" ("standing_order_id", "scheduled_for", "attempt");
+
+COMMENT ON COLUMN "standing_order_runs"."scheduled_for" IS 'the date of the run in the schedule, the attempt is made later';
+
+COMMENT ON COLUMN "standing_order_runs"."outcome" IS 'succeeded, pending_approval, retrying or failed';
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS "hold_captures";
+
+DROP TABLE IF EXISTS "holds";
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "closed_account_zero_balance";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "closed_account_zero_balance" CHECK ("status" <> 'closed' OR "balance" = 0);
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "balance_within_overdraft_limit";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_within_overdraft_limit" CHECK ("balance" >= -"overdraft_limit");
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "held_amount_not_negative";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "held_amount";
+
+COMMENT ON COLUMN "accounts"."balance" IS 'cannot go below -overdraft_limit';
@@ -0,0 +1,77 @@
+-- A hold reserves money on an account, like a card payment that is authorized now and captured later
+-- The money stays in the balance, but it is no longer available: the available balance is balance - held_amount
+ALTER TABLE "accounts" ADD COLUMN "held_amount" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "held_amount_not_negative" CHECK ("held_amount" >= 0);
+
+-- The overdraft limit is now checked against the available balance, so a transfer or a withdrawal
+-- can never use the money reserved by a hold. It keeps its name, since the store maps it to ErrInsufficientFunds
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "balance_within_overdraft_limit";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_within_overdraft_limit" CHECK ("balance" - "held_amount" >= -"overdraft_limit");
+
+-- an account with active holds cannot be closed either
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "closed_account_zero_balance";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "closed_account_zero_balance"
+    CHECK ("status" <> 'closed' OR ("balance" = 0 AND "held_amount" = 0));
+
+COMMENT ON COLUMN "accounts"."balance" IS 'balance - held_amount cannot go below -overdraft_limit';
+
+COMMENT ON COLUMN "accounts"."held_amount" IS 'the sum of what the active holds of the account have not captured yet';
+
+-- The holds of the accounts, the money is reserved on account_id and captured to to_account_id
+-- The status of a hold is:
+--   active: the money is reserved, it can still be captured or released
+--   captured: the whole amount was captured
+--   released: what was not captured went back to the available balance
+--   expired: the hold was not captured in time, what was not captured went back to the available balance
+-- A hold can be captured several times, until captured_amount reaches amount
+CREATE TABLE "holds" (
+                         "id" bigserial PRIMARY KEY,
+                         "account_id" bigint NOT NULL,
+                         "to_account_id" bigint NOT NULL,
+                         "amount" bigint NOT NULL,
+                         "captured_amount" bigint NOT NULL DEFAULT 0,
+                         "currency" varchar NOT NULL,
+                         "status" varchar NOT NULL DEFAULT 'active',
+                         "created_by" varchar NOT NULL,
+                         "expires_at" timestamptz NOT NULL,
+                         "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
+
+ALTER TABLE "holds" ADD CONSTRAINT "hold_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "holds" ADD CONSTRAINT "hold_captured_amount_valid" CHECK ("captured_amount" >= 0 AND "captured_amount" <= "amount");
+
+ALTER TABLE "holds" ADD CONSTRAINT "hold_status_valid" CHECK ("status" IN ('active', 'captured', 'released', 'expired'));
+
+CREATE INDEX ON "holds" ("account_id", "created_at", "id");
+
+-- the scheduler looks for the active holds that have expired, this index only has them
+CREATE INDEX ON "holds" ("expires_at") WHERE "status" = 'active';
+
+COMMENT ON COLUMN "holds"."amount" IS 'in the currency of the account, without the fee';
+
+COMMENT ON COLUMN "holds"."status" IS 'active, captured, released or expired';
+
+-- Each capture of a hold is a normal transfer, so it has its postings in the ledger like any other transfer
+CREATE TABLE "hold_captures" (
+                                 "id" bigserial PRIMARY KEY,
+                                 "hold_id" bigint NOT NULL,
+                                 "transfer_id" bigint UNIQUE NOT NULL,
+                                 "amount" bigint NOT NULL,
+                                 "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "hold_captures" ADD FOREIGN KEY ("hold_id") REFERENCES "holds" ("id");
+
+ALTER TABLE "hold_captures" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "hold_captures" ("hold_id", "id");
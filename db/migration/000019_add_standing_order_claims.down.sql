@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "standing_orders" DROP COLUMN IF EXISTS "claimed_until";
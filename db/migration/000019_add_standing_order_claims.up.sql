@@ -0,0 +1,6 @@
+-- The scheduler claims a standing order in a short transaction, then makes its transfer outside of it
+-- claimed_until hides the standing order from the other servers while its run is made,
+-- if the server stops before the run is saved, another server claims it again once claimed_until is over
+ALTER TABLE "standing_orders" ADD COLUMN "claimed_until" timestamptz;
+
+COMMENT ON COLUMN "standing_orders"."claimed_until" IS 'the run is being made by a server until then, NULL when it is not claimed';
@@ -0,0 +1,8 @@
+DELETE FROM "standing_order_runs" WHERE "outcome" = 'skipped';
+
+ALTER TABLE IF EXISTS "standing_order_runs" DROP CONSTRAINT IF EXISTS "standing_order_run_outcome_valid";
+
+ALTER TABLE "standing_order_runs" ADD CONSTRAINT "standing_order_run_outcome_valid"
+    CHECK ("outcome" IN ('succeeded', 'pending_approval', 'retrying', 'failed'));
+
+COMMENT ON COLUMN "standing_order_runs"."outcome" IS 'succeeded, pending_approval, retrying or failed';
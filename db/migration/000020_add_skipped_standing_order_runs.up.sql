@@ -0,0 +1,9 @@
+-- A paused standing order doesn't make the runs it missed when it is resumed, the rent of three months is not paid at once:
+-- each missed run is recorded as skipped, with attempt 0 since no transfer was tried,
+-- and the standing order moves to its first run at or after the time it is resumed
+ALTER TABLE IF EXISTS "standing_order_runs" DROP CONSTRAINT IF EXISTS "standing_order_run_outcome_valid";
+
+ALTER TABLE "standing_order_runs" ADD CONSTRAINT "standing_order_run_outcome_valid"
+    CHECK ("outcome" IN ('succeeded', 'pending_approval', 'retrying', 'failed', 'skipped'));
+
+COMMENT ON COLUMN "standing_order_runs"."outcome" IS 'succeeded, pending_approval, retrying, failed or skipped';
@@ -0,0 +1,7 @@
+-- The deposits and withdrawals are made by a banker, since they bring money into the bank or take it out
+-- created_by records who made them, so every journal entry that doesn't come from a customer can be traced back
+ALTER TABLE "journal_entries" ADD COLUMN "created_by" varchar;
+
+ALTER TABLE "journal_entries" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
+
+COMMENT ON COLUMN "journal_entries"."created_by" IS 'the banker who made a deposit or a withdrawal, NULL for the other kinds';
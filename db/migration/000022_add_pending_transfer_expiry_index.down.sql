@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "pending_transfers_expires_at_idx";
@@ -0,0 +1,3 @@
+-- The scheduler expires the pending transfers that were not reviewed in time, see ClaimExpiredPendingTransfer
+-- only the transfers that can still be reviewed are indexed, like the expired holds
+CREATE INDEX ON "pending_transfers" ("expires_at") WHERE "status" IN ('pending', 'approved');
@@ -41,11 +41,34 @@ SELECT * FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE;
 
+/*
+ A user must only be able to list his own accounts,
+ so we filter the accounts by owner
+ */
+
 -- name: ListAccounts :many
 SELECT * FROM accounts
+WHERE owner = $1
 ORDER BY id
-LIMIT $1
-OFFSET $2;
+LIMIT $2
+OFFSET $3;
+
+/*
+ This is the keyset version of ListAccounts
+ Instead of skipping rows with OFFSET, we continue right after the last account of the previous page.
+ The accounts are sorted by (created_at, id): created_at is the natural order of a history
+ and id breaks the ties between rows created at the same time.
+ With the index on (owner, created_at, id), postgres jumps directly to the first row of the page,
+ so every page is as fast as the first one, and no row is skipped or repeated when new rows are inserted
+ For the first page, the zero time and the id 0 are used, which are before any account
+ */
+
+-- name: ListAccountsAfter :many
+SELECT * FROM accounts
+WHERE owner = sqlc.arg(owner)
+  AND (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
 
 -- name: UpdateAccount :one
 UPDATE accounts
@@ -53,9 +76,6 @@ SET balance = $2
 WHERE id = $1
 RETURNING *;
 
--- name: DeleteAccount :exec
-DELETE FROM accounts WHERE id = $1;
-
 
 /*
  Currently we have to run 2 queries to get the account and update its balance
@@ -72,4 +92,71 @@ DELETE FROM accounts WHERE id = $1;
 UPDATE accounts
 SET balance = balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+/*
+ This query reserves money on an account, or gives it back, when a hold is created, captured, released or expired
+ The amount is positive to reserve money and negative to give it back
+ Like AddAccountBalance it locks the row, and the "balance_within_overdraft_limit" constraint
+ fails if the account doesn't have enough available money for the hold
+ */
+
+-- name: AddAccountHeldAmount :one
+UPDATE accounts
+SET held_amount = held_amount + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+/*
+ This query changes how far below zero the balance of an account can go
+
+ Like AddAccountBalance, this UPDATE does not touch the primary key, so Postgres takes the same
+ 'FOR NO KEY UPDATE' row lock as GetAccountForUpdate. A transfer and a limit change on the same
+ account are therefore serialized, and the CHECK constraint "balance_within_overdraft_limit"
+ is always evaluated against the latest committed balance and limit.
+ If the account is already more overdrawn than the new limit allows, the update fails
+ */
+
+-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = sqlc.arg(overdraft_limit)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+/*
+ This query changes the status of an account: active, frozen or closed
+ It takes the same row lock as AddAccountBalance, so a transfer and a status change on the same account
+ are serialized. An account can only be closed with a zero balance, this is checked by the
+ "closed_account_zero_balance" constraint
+ */
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+/*
+ A system account belongs to the bank, its owner is one of the system users of db/utils/system_account.go
+ There is one system account per owner and currency, it is created the first time it is needed
+ It can go as far below zero as a bigint allows, since it takes the other side of any posting
+
+ If another transaction is creating the same account, ON CONFLICT waits for it and then does nothing,
+ so GetSystemAccount always finds exactly one account afterwards
+ */
+
+-- name: CreateSystemAccount :exec
+INSERT INTO accounts (
+                      owner,
+                      balance,
+                      currency,
+                      overdraft_limit
+) VALUES (
+          sqlc.arg(owner), 0, sqlc.arg(currency), 9223372036854775807
+         ) ON CONFLICT (owner, currency) WHERE status <> 'closed' DO NOTHING;
+
+-- name: GetSystemAccount :one
+SELECT * FROM accounts
+WHERE owner = $1
+  AND currency = $2
+  AND status <> 'closed'
+LIMIT 1;
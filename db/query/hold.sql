@@ -0,0 +1,71 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+    account_id,
+    to_account_id,
+    amount,
+    currency,
+    created_by,
+    expires_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6
+         ) RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+/*
+ The hold is locked while it is captured, released or expired,
+ so two captures cannot take the same money, and a hold is never captured after it was released
+ */
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+/*
+ The holds of an account are listed with the keyset pagination, see ListAccountsAfter
+ The status is optional, all the holds are listed when it is NULL
+ */
+
+-- name: ListHolds :many
+SELECT * FROM holds
+WHERE account_id = sqlc.arg(account_id)
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+  AND (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateHold :one
+UPDATE holds
+SET captured_amount = $2, status = $3
+WHERE id = $1
+RETURNING *;
+
+/*
+ This is how the scheduler takes the next hold that has expired, like ClaimDueStandingOrder
+ SKIP LOCKED ignores the holds that are being captured or released, and the ones taken by another server
+ */
+
+-- name: ClaimExpiredHold :one
+SELECT * FROM holds
+WHERE status = 'active'
+  AND expires_at <= sqlc.arg(now)::timestamptz
+ORDER BY expires_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: CreateHoldCapture :one
+INSERT INTO hold_captures (
+    hold_id,
+    transfer_id,
+    amount
+) VALUES (
+             $1, $2, $3
+         ) RETURNING *;
+
+-- name: ListHoldCaptures :many
+SELECT * FROM hold_captures
+WHERE hold_id = $1
+ORDER BY id;
@@ -0,0 +1,29 @@
+/*
+ The key is created at the very beginning of the transaction
+ If another transaction is already using the same key, this insert waits for it to finish
+ and then fails with a unique violation, so the same request can never be processed twice
+ */
+
+-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+    username,
+    key,
+    request_hash
+) VALUES (
+             $1, $2, $3
+         ) RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE username = $1 AND key = $2 LIMIT 1;
+
+/*
+ Once the request has been processed, we save its response
+ so that a replay can return exactly the same thing
+ */
+
+-- name: UpdateIdempotencyKeyResponse :one
+UPDATE idempotency_keys
+SET response_status = $3, response_body = $4
+WHERE username = $1 AND key = $2
+RETURNING *;
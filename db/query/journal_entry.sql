@@ -0,0 +1,16 @@
+-- name: CreateJournalEntry :one
+INSERT INTO journal_entries (
+    kind,
+    transfer_id,
+    created_by
+) VALUES (
+             $1, $2, $3
+         ) RETURNING *;
+
+-- name: GetJournalEntry :one
+SELECT * FROM journal_entries
+WHERE id = $1 LIMIT 1;
+
+-- name: GetTransferJournalEntry :one
+SELECT * FROM journal_entries
+WHERE transfer_id = sqlc.arg(transfer_id)::bigint LIMIT 1;
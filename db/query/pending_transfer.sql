@@ -0,0 +1,85 @@
+-- name: CreatePendingTransfer :one
+INSERT INTO pending_transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    currency,
+    requested_by,
+    expires_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6
+         ) RETURNING *;
+
+-- name: GetPendingTransfer :one
+SELECT * FROM pending_transfers
+WHERE id = $1 LIMIT 1;
+
+/*
+ The pending transfer is locked while it is reviewed or executed,
+ so two bankers cannot approve and reject it at the same time, and it is never executed twice
+ */
+
+-- name: GetPendingTransferForUpdate :one
+SELECT * FROM pending_transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+/*
+ The bankers list the pending transfers with the keyset pagination, see ListAccountsAfter
+ The status is optional, all the pending transfers are listed when it is NULL
+ */
+
+-- name: ListPendingTransfers :many
+SELECT * FROM pending_transfers
+WHERE (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+  AND (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ReviewPendingTransfer :one
+UPDATE pending_transfers
+SET status = $2, reviewed_by = $3, reviewed_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ExecutePendingTransfer :one
+UPDATE pending_transfers
+SET status = 'executed', transfer_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ExpirePendingTransfer :one
+UPDATE pending_transfers
+SET status = 'expired'
+WHERE id = $1
+RETURNING *;
+
+/*
+ This is how the scheduler takes the next pending transfer that was not reviewed in time, like ClaimExpiredHold
+ An approved transfer that could not be executed expires too, since it can be approved again until then
+ SKIP LOCKED ignores the pending transfers that are being reviewed, and the ones taken by another server
+ */
+
+-- name: ClaimExpiredPendingTransfer :one
+SELECT * FROM pending_transfers
+WHERE status IN ('pending', 'approved')
+  AND expires_at <= sqlc.arg(now)::timestamptz
+ORDER BY expires_at, id
+LIMIT 1
+FOR NO KEY UPDATE SKIP LOCKED;
+
+-- name: CreatePendingTransferEvent :one
+INSERT INTO pending_transfer_events (
+    pending_transfer_id,
+    action,
+    actor,
+    status,
+    note
+) VALUES (
+             $1, $2, $3, $4, $5
+         ) RETURNING *;
+
+-- name: ListPendingTransferEvents :many
+SELECT * FROM pending_transfer_events
+WHERE pending_transfer_id = $1
+ORDER BY id;
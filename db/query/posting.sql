@@ -0,0 +1,41 @@
+/*
+ A posting adds its amount to the balance of one account, it always belongs to a journal entry
+ The postings are only created through postJournalEntry in store.go, which checks that they net to zero
+ */
+
+-- name: CreatePosting :one
+INSERT INTO postings (
+    journal_entry_id,
+    account_id,
+    currency,
+    amount
+) VALUES (
+             $1, $2, $3, $4
+         ) RETURNING *;
+
+-- name: GetPosting :one
+SELECT * FROM postings
+WHERE id = $1 LIMIT 1;
+
+-- name: ListPostings :many
+SELECT * FROM postings
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+    OFFSET $3;
+
+/*
+ This is the keyset version of ListPostings, see ListAccountsAfter
+ */
+
+-- name: ListPostingsAfter :many
+SELECT * FROM postings
+WHERE account_id = sqlc.arg(account_id)
+  AND (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListJournalEntryPostings :many
+SELECT * FROM postings
+WHERE journal_entry_id = $1
+ORDER BY id;
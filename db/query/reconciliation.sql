@@ -0,0 +1,85 @@
+/*
+ These queries are used by the ledger reconciliation, see ReconcileLedger in store.go
+ They only read the tables, and they must run in the same snapshot so that the counts
+ and the discrepancies describe the same state of the ledger
+ */
+
+-- name: CountAccounts :one
+SELECT COUNT(*) FROM accounts;
+
+-- name: CountTransfers :one
+SELECT COUNT(*) FROM transfers;
+
+-- name: CountJournalEntries :one
+SELECT COUNT(*) FROM journal_entries;
+
+/*
+ The balance of an account must always be the sum of its postings
+ The LEFT JOIN keeps the accounts without any posting, their sum is 0
+ */
+
+-- name: ListBalanceDiscrepancies :many
+SELECT a.id AS account_id,
+       a.currency,
+       a.balance,
+       COALESCE(SUM(p.amount), 0)::bigint AS postings_total
+FROM accounts a
+LEFT JOIN postings p ON p.account_id = a.id
+GROUP BY a.id
+HAVING a.balance <> COALESCE(SUM(p.amount), 0)
+ORDER BY a.id;
+
+/*
+ The held amount of an account must always be what its active holds have not captured yet
+ */
+
+-- name: ListHeldAmountDiscrepancies :many
+SELECT a.id AS account_id,
+       a.currency,
+       a.held_amount,
+       COALESCE(SUM(h.amount - h.captured_amount) FILTER (WHERE h.status = 'active'), 0)::bigint AS holds_total
+FROM accounts a
+LEFT JOIN holds h ON h.account_id = a.id
+GROUP BY a.id
+HAVING a.held_amount <> COALESCE(SUM(h.amount - h.captured_amount) FILTER (WHERE h.status = 'active'), 0)
+ORDER BY a.id;
+
+/*
+ A transfer must have exactly one journal entry, with one posting that debits the sender with the amount and the fee
+ and one posting that credits the receiver with the credited amount, in its own currency
+ The other postings of the journal entry, if any, are on the system accounts, like the foreign exchange or the fees
+ The postings are counted with FILTER, so we can tell which one is missing or wrong
+ */
+
+-- name: ListTransferDiscrepancies :many
+SELECT t.id AS transfer_id,
+       t.from_account_id,
+       t.to_account_id,
+       t.amount,
+       t.fee,
+       t.credited_amount,
+       COUNT(DISTINCT j.id) AS journal_entry_count,
+       COUNT(p.id) FILTER (WHERE p.account_id = t.from_account_id AND p.amount = -(t.amount + t.fee)) AS debit_count,
+       COUNT(p.id) FILTER (WHERE p.account_id = t.to_account_id AND p.amount = t.credited_amount) AS credit_count
+FROM transfers t
+LEFT JOIN journal_entries j ON j.transfer_id = t.id
+LEFT JOIN postings p ON p.journal_entry_id = j.id
+GROUP BY t.id
+HAVING COUNT(DISTINCT j.id) <> 1
+    OR COUNT(p.id) FILTER (WHERE p.account_id = t.from_account_id AND p.amount = -(t.amount + t.fee)) <> 1
+    OR COUNT(p.id) FILTER (WHERE p.account_id = t.to_account_id AND p.amount = t.credited_amount) <> 1
+ORDER BY t.id;
+
+/*
+ The "journal_entry_balanced" trigger already refuses a journal entry that doesn't net to zero
+ This checks it again, so the reconciliation doesn't depend on the trigger being there
+ */
+
+-- name: ListUnbalancedJournalEntries :many
+SELECT journal_entry_id,
+       currency,
+       SUM(amount)::bigint AS total
+FROM postings
+GROUP BY journal_entry_id, currency
+HAVING SUM(amount) <> 0
+ORDER BY journal_entry_id, currency;
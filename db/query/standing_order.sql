@@ -0,0 +1,118 @@
+-- name: CreateStandingOrder :one
+INSERT INTO standing_orders (
+    from_account_id,
+    to_account_id,
+    amount,
+    currency,
+    frequency,
+    interval_count,
+    start_at,
+    end_at,
+    next_run_at,
+    created_by
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+         ) RETURNING *;
+
+-- name: GetStandingOrder :one
+SELECT * FROM standing_orders
+WHERE id = $1 LIMIT 1;
+
+/*
+ The standing order is locked while its run is saved or while its owner changes it,
+ so a change never overwrites the status that a run has just saved
+ */
+
+-- name: GetStandingOrderForUpdate :one
+SELECT * FROM standing_orders
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListStandingOrders :many
+SELECT * FROM standing_orders
+WHERE from_account_id = $1
+ORDER BY id;
+
+/*
+ The owner of the account can change the amount and the end of a standing order, and pause, resume or cancel it
+ The schedule itself cannot change, a new standing order must be created instead
+ */
+
+-- name: UpdateStandingOrder :one
+UPDATE standing_orders
+SET amount = $2, end_at = $3, status = $4
+WHERE id = $1
+RETURNING *;
+
+/*
+ A paused standing order that is resumed moves past the runs it missed, its claim is kept:
+ if a run is being made, the scheduler saves it once the transfer is done
+ */
+
+-- name: SkipStandingOrderRuns :one
+UPDATE standing_orders
+SET next_run_at = $2,
+    run_count = $3,
+    failed_attempts = 0,
+    retry_at = NULL,
+    status = $4
+WHERE id = $1
+RETURNING *;
+
+/*
+ This is how the scheduler takes the next standing order to run
+ FOR UPDATE locks it until it is claimed, and SKIP LOCKED ignores the standing orders that are already locked.
+ The claimed ones are ignored until their claim is over, so each server of the bank takes a different one
+ and a run is never made twice at the same time
+ */
+
+-- name: ClaimDueStandingOrder :one
+SELECT * FROM standing_orders
+WHERE status = 'active'
+  AND COALESCE(retry_at, next_run_at) <= sqlc.arg(now)::timestamptz
+  AND (claimed_until IS NULL OR claimed_until <= sqlc.arg(now)::timestamptz)
+ORDER BY COALESCE(retry_at, next_run_at), id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: ClaimStandingOrder :one
+UPDATE standing_orders
+SET claimed_until = $2
+WHERE id = $1
+RETURNING *;
+
+/*
+ Saving the next run of a standing order also ends its claim
+ */
+
+-- name: ScheduleStandingOrder :one
+UPDATE standing_orders
+SET next_run_at = $2,
+    run_count = $3,
+    failed_attempts = $4,
+    retry_at = $5,
+    status = $6,
+    claimed_until = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateStandingOrderRun :one
+INSERT INTO standing_order_runs (
+    standing_order_id,
+    scheduled_for,
+    attempt,
+    max_attempts,
+    outcome,
+    transfer_id,
+    pending_transfer_id,
+    error,
+    retry_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9
+         ) RETURNING *;
+
+-- name: ListStandingOrderRuns :many
+SELECT * FROM standing_order_runs
+WHERE standing_order_id = $1
+ORDER BY id DESC
+LIMIT $2;
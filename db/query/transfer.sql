@@ -2,9 +2,14 @@
 INSERT INTO transfers (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    from_currency,
+    to_currency,
+    exchange_rate,
+    credited_amount,
+    fee
 ) VALUES (
-             $1, $2, $3
+             $1, $2, $3, $4, $5, $6, $7, $8
          ) RETURNING *;
 
 -- name: GetTransfer :one
@@ -18,4 +23,33 @@ WHERE
         to_account_id = $2
 ORDER BY id
 LIMIT $3
-    OFFSET $4;
\ No newline at end of file
+    OFFSET $4;
+
+/*
+ This is the keyset version of ListTransfers, see ListAccountsAfter
+ It also filters the transfers, so the client doesn't have to fetch all of them:
+
+        - outgoing and incoming select the direction: the transfers sent by the account, received by the account, or both
+        - since and until select a period, since is included and until is excluded
+        - min_amount and max_amount select a range of amounts, both are included
+          they are in the currency of the account: the amount sent for an outgoing transfer,
+          and the credited amount for an incoming one, since it can be in another currency
+
+ The period and the amounts are optional, we use sqlc.narg so that they are NULL when the client doesn't send them
+ and a NULL filter is always true. lib/pq doesn't reuse the plan of the query, so postgres plans it with the actual
+ values and only uses the conditions and the indexes of the filters that are set
+ */
+
+-- name: ListTransfersFiltered :many
+SELECT * FROM transfers
+WHERE ((from_account_id = sqlc.arg(account_id) AND sqlc.arg(outgoing)::boolean)
+    OR (to_account_id = sqlc.arg(account_id) AND sqlc.arg(incoming)::boolean))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+  AND (sqlc.narg(min_amount)::bigint IS NULL
+    OR CASE WHEN to_account_id = sqlc.arg(account_id) THEN credited_amount ELSE amount END >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL
+    OR CASE WHEN to_account_id = sqlc.arg(account_id) THEN credited_amount ELSE amount END <= sqlc.narg(max_amount))
+  AND (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
\ No newline at end of file
@@ -0,0 +1,62 @@
+/*
+ The limits are saved with an upsert, so a banker can set them without knowing if they were already set
+ A NULL limit of an account means that the limit of its currency is used
+ The approval threshold is only set on the currency, see the pending transfers
+ */
+
+-- name: SetCurrencyTransferLimit :one
+INSERT INTO currency_transfer_limits (
+    currency,
+    max_amount,
+    max_daily_amount,
+    max_daily_count,
+    approval_threshold
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+ON CONFLICT (currency) DO UPDATE
+SET max_amount = EXCLUDED.max_amount,
+    max_daily_amount = EXCLUDED.max_daily_amount,
+    max_daily_count = EXCLUDED.max_daily_count,
+    approval_threshold = EXCLUDED.approval_threshold,
+    updated_at = now()
+RETURNING *;
+
+-- name: SetAccountTransferLimit :one
+INSERT INTO account_transfer_limits (
+    account_id,
+    max_amount,
+    max_daily_amount,
+    max_daily_count
+) VALUES (
+             $1, $2, $3, $4
+         )
+ON CONFLICT (account_id) DO UPDATE
+SET max_amount = EXCLUDED.max_amount,
+    max_daily_amount = EXCLUDED.max_daily_amount,
+    max_daily_count = EXCLUDED.max_daily_count,
+    updated_at = now()
+RETURNING *;
+
+-- name: GetCurrencyTransferLimit :one
+SELECT * FROM currency_transfer_limits
+WHERE currency = $1 LIMIT 1;
+
+-- name: GetAccountTransferLimit :one
+SELECT * FROM account_transfer_limits
+WHERE account_id = $1 LIMIT 1;
+
+/*
+ This is what a user has already sent in a currency in the last 24 hours, it is compared with the daily limits of his account
+ The accounts that he closed are included, so closing an account and opening a new one doesn't reset the limits
+ The window is rolling, so it uses the time of the database and the index of the keyset pagination
+ */
+
+-- name: GetTransferVelocity :one
+SELECT COUNT(*) AS transfer_count,
+       COALESCE(SUM(t.amount), 0)::bigint AS total_amount
+FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+WHERE a.owner = $1
+  AND a.currency = $2
+  AND t.created_at > now() - interval '24 hours';
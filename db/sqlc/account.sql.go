@@ -7,6 +7,9 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/elmas23/simplebank/money"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
@@ -24,12 +27,12 @@ const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, status, held_amount
 `
 
 type AddAccountBalanceParams struct {
-	Amount int64 `json:"amount"`
-	ID     int64 `json:"id"`
+	Amount money.Amount `json:"amount"`
+	ID     int64        `json:"id"`
 }
 
 func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
@@ -41,6 +44,44 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
+	)
+	return i, err
+}
+
+const addAccountHeldAmount = `-- name: AddAccountHeldAmount :one
+/*
+ This query reserves money on an account, or gives it back, when a hold is created, captured, released or expired
+ The amount is positive to reserve money and negative to give it back
+ Like AddAccountBalance it locks the row, and the "balance_within_overdraft_limit" constraint
+ fails if the account doesn't have enough available money for the hold
+ */
+
+UPDATE accounts
+SET held_amount = held_amount + $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, status, held_amount
+`
+
+type AddAccountHeldAmountParams struct {
+	Amount money.Amount `json:"amount"`
+	ID     int64        `json:"id"`
+}
+
+func (q *Queries) AddAccountHeldAmount(ctx context.Context, arg AddAccountHeldAmountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, addAccountHeldAmount, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
 	)
 	return i, err
 }
@@ -52,13 +93,13 @@ INSERT INTO accounts (
                       currency
 ) VALUES (
           $1, $2, $3
-         ) RETURNING id, owner, balance, currency, created_at
+         ) RETURNING id, owner, balance, currency, created_at, overdraft_limit, status, held_amount
 `
 
 type CreateAccountParams struct {
-	Owner    string `json:"owner"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+	Owner    string       `json:"owner"`
+	Balance  money.Amount `json:"balance"`
+	Currency string       `json:"currency"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
@@ -70,16 +111,40 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
 	)
 	return i, err
 }
 
-const deleteAccount = `-- name: DeleteAccount :exec
-DELETE FROM accounts WHERE id = $1
+const createSystemAccount = `-- name: CreateSystemAccount :exec
+/*
+ A system account belongs to the bank, its owner is one of the system users of db/utils/system_account.go
+ There is one system account per owner and currency, it is created the first time it is needed
+ It can go as far below zero as a bigint allows, since it takes the other side of any posting
+
+ If another transaction is creating the same account, ON CONFLICT waits for it and then does nothing,
+ so GetSystemAccount always finds exactly one account afterwards
+ */
+
+INSERT INTO accounts (
+                      owner,
+                      balance,
+                      currency,
+                      overdraft_limit
+) VALUES (
+          $1, 0, $2, 9223372036854775807
+         ) ON CONFLICT (owner, currency) WHERE status <> 'closed' DO NOTHING
 `
 
-func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteAccount, id)
+type CreateSystemAccountParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) CreateSystemAccount(ctx context.Context, arg CreateSystemAccountParams) error {
+	_, err := q.db.ExecContext(ctx, createSystemAccount, arg.Owner, arg.Currency)
 	return err
 }
 
@@ -94,7 +159,7 @@ const getAccount = `-- name: GetAccount :one
 
  */
 
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, status, held_amount FROM accounts
 WHERE id = $1
 LIMIT 1
 `
@@ -108,6 +173,9 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
 	)
 	return i, err
 }
@@ -128,7 +196,7 @@ const getAccountForUpdate = `-- name: GetAccountForUpdate :one
  lock. Thus we no longer have the deadlock issue
  */
 
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, status, held_amount FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -142,24 +210,125 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
+	)
+	return i, err
+}
+
+const getSystemAccount = `-- name: GetSystemAccount :one
+SELECT id, owner, balance, currency, created_at, overdraft_limit, status, held_amount FROM accounts
+WHERE owner = $1
+  AND currency = $2
+  AND status <> 'closed'
+LIMIT 1
+`
+
+type GetSystemAccountParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getSystemAccount, arg.Owner, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+/*
+ A user must only be able to list his own accounts,
+ so we filter the accounts by owner
+ */
+
+SELECT id, owner, balance, currency, created_at, overdraft_limit, status, held_amount FROM accounts
+WHERE owner = $1
 ORDER BY id
-LIMIT $1
-OFFSET $2
+LIMIT $2
+OFFSET $3
 `
 
 type ListAccountsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	Owner  string `json:"owner"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
 }
 
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Owner, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.Status,
+			&i.HeldAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsAfter = `-- name: ListAccountsAfter :many
+/*
+ This is the keyset version of ListAccounts
+ Instead of skipping rows with OFFSET, we continue right after the last account of the previous page.
+ The accounts are sorted by (created_at, id): created_at is the natural order of a history
+ and id breaks the ties between rows created at the same time.
+ With the index on (owner, created_at, id), postgres jumps directly to the first row of the page,
+ so every page is as fast as the first one, and no row is skipped or repeated when new rows are inserted
+ For the first page, the zero time and the id 0 are used, which are before any account
+ */
+
+SELECT id, owner, balance, currency, created_at, overdraft_limit, status, held_amount FROM accounts
+WHERE owner = $1
+  AND (created_at, id) > ($2::timestamptz, $3::bigint)
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListAccountsAfterParams struct {
+	Owner          string    `json:"owner"`
+	AfterCreatedAt time.Time `json:"after_created_at"`
+	AfterID        int64     `json:"after_id"`
+	Limit          int32     `json:"limit"`
+}
+
+func (q *Queries) ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsAfter,
+		arg.Owner,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -173,6 +342,9 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.Status,
+			&i.HeldAmount,
 		); err != nil {
 			return nil, err
 		}
@@ -191,12 +363,12 @@ const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, status, held_amount
 `
 
 type UpdateAccountParams struct {
-	ID      int64 `json:"id"`
-	Balance int64 `json:"balance"`
+	ID      int64        `json:"id"`
+	Balance money.Amount `json:"balance"`
 }
 
 func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
@@ -208,6 +380,82 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
+	)
+	return i, err
+}
+
+const updateAccountOverdraftLimit = `-- name: UpdateAccountOverdraftLimit :one
+/*
+ This query changes how far below zero the balance of an account can go
+
+ Like AddAccountBalance, this UPDATE does not touch the primary key, so Postgres takes the same
+ 'FOR NO KEY UPDATE' row lock as GetAccountForUpdate. A transfer and a limit change on the same
+ account are therefore serialized, and the CHECK constraint "balance_within_overdraft_limit"
+ is always evaluated against the latest committed balance and limit.
+ If the account is already more overdrawn than the new limit allows, the update fails
+ */
+
+UPDATE accounts
+SET overdraft_limit = $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, status, held_amount
+`
+
+type UpdateAccountOverdraftLimitParams struct {
+	OverdraftLimit money.Amount `json:"overdraft_limit"`
+	ID             int64        `json:"id"`
+}
+
+func (q *Queries) UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountOverdraftLimit, arg.OverdraftLimit, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+/*
+ This query changes the status of an account: active, frozen or closed
+ It takes the same row lock as AddAccountBalance, so a transfer and a status change on the same account
+ are serialized. An account can only be closed with a zero balance, this is checked by the
+ "closed_account_zero_balance" constraint
+ */
+
+UPDATE accounts
+SET status = $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, status, held_amount
+`
+
+type UpdateAccountStatusParams struct {
+	Status string `json:"status"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.Status, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldAmount,
 	)
 	return i, err
 }
@@ -2,8 +2,8 @@ package db
 
 import (
 	"context"
-	"database/sql"
 	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/money"
 	"github.com/stretchr/testify/require"
 	"testing"
 	"time"
@@ -15,12 +15,27 @@ import (
 // to rely on another test
 // it does not have the Test prefix; so it won't be run as part of the unit test
 func createRandomAccount(t *testing.T) Account {
+	return createRandomAccountWithCurrency(t, utils.GenerateBalance(), utils.GenerateCurrency())
+}
+
+// createRandomAccountWithBalance does the same as createRandomAccount but with a specific balance
+// it is useful for the transfer tests, where the sender must have enough money
+// All these accounts use the same currency, so the transfers between them are not converted
+func createRandomAccountWithBalance(t *testing.T, balance money.Amount) Account {
+	return createRandomAccountWithCurrency(t, balance, "USD")
+}
+
+// createRandomAccountWithCurrency creates an account with a specific balance and currency
+func createRandomAccountWithCurrency(t *testing.T, balance money.Amount, currency string) Account {
+	// Every account must belong to an existing user
+	user := createRandomUser(t)
+
 	// Since our methods takes a CreateAccountParams as parameter
 	// We are setting some mock value to it that we can use to test our methods
 	arg := CreateAccountParams{
-		Owner:    utils.GenerateOwner(),
-		Balance:  utils.GenerateBalance(),
-		Currency: utils.GenerateCurrency(),
+		Owner:    user.Username,
+		Balance:  balance,
+		Currency: currency,
 	}
 	// Now we make the call to the CreateAccount method usinf the testQueries variable
 	// that we created in main test
@@ -32,6 +47,10 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, account.Owner, arg.Owner)
 	require.Equal(t, account.Balance, arg.Balance)
 	require.Equal(t, account.Currency, arg.Currency)
+	// by default an account cannot go below zero
+	require.Zero(t, account.OverdraftLimit)
+	// and a new account is always active
+	require.Equal(t, utils.ActiveStatus, account.Status)
 
 	// we also want to check that account id is generated by postgres directly
 	require.NotZero(t, account.ID)
@@ -93,42 +112,106 @@ func TestUpdateAccount(t *testing.T) {
 
 }
 
-func TestDeleteAccount(t *testing.T) {
-	account1 := createRandomAccount(t)
-
-	err := testQueries.DeleteAccount(context.Background(), account1.ID)
-	// We should check that there is no error
-	require.NoError(t, err)
-
-	// Then we try to get the account , and normally it should be empty
-	account2, err := testQueries.GetAccount(context.Background(), account1.ID)
-	// Here an error should happen since the acount is deleted
-	require.Error(t, err)
-	// We check further that the error is specifically an ErrNoRows.Error()
-	require.EqualError(t, err, sql.ErrNoRows.Error())
-	// we should also check that account2 is empty
-	require.Empty(t, account2)
-}
-
 func TestListAccounts(t *testing.T) {
+	// each random account belongs to a new random user
+	// so we keep the last one to list the accounts of its owner
+	var lastAccount Account
 	for i := 0; i < 10; i++ {
-		createRandomAccount(t)
+		lastAccount = createRandomAccount(t)
 	}
 
 	arg := ListAccountsParams{
+		Owner:  lastAccount.Owner,
 		Limit:  5,
-		Offset: 5,
+		Offset: 0,
 	}
 
 	accounts, err := testQueries.ListAccounts(context.Background(), arg)
 	// we check that there is no error
 	require.NoError(t, err)
-	// Since the limit is 5 we should check that the return list is of length 5
-	require.Len(t, accounts, 5)
+	// the owner has at least the last account
+	require.NotEmpty(t, accounts)
 
 	// We then run through each account
-	// and check that they are not empty
+	// and check that they all belong to the owner
 	for _, account := range accounts {
 		require.NotEmpty(t, account)
+		require.Equal(t, lastAccount.Owner, account.Owner)
+	}
+}
+
+func TestUpdateAccountOverdraftLimit(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	arg := UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: utils.GenerateAmount(),
+	}
+
+	account2, err := testQueries.UpdateAccountOverdraftLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, account1.Balance, account2.Balance)
+	require.Equal(t, arg.OverdraftLimit, account2.OverdraftLimit)
+}
+
+func TestUpdateAccountStatus(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	arg := UpdateAccountStatusParams{
+		ID:     account1.ID,
+		Status: utils.FrozenStatus,
+	}
+
+	account2, err := testQueries.UpdateAccountStatus(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, account1.Balance, account2.Balance)
+	require.Equal(t, utils.FrozenStatus, account2.Status)
+
+	// the database refuses any other status
+	arg.Status = "deleted"
+	_, err = testQueries.UpdateAccountStatus(context.Background(), arg)
+	require.Error(t, err)
+}
+
+func TestListAccountsAfter(t *testing.T) {
+	// a user can have one account per currency, so we create all of them for the same user
+	user := createRandomUser(t)
+	currencies := []string{"EUR", "USD", "CAD"}
+	for _, currency := range currencies {
+		_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  0,
+			Currency: currency,
+		})
+		require.NoError(t, err)
+	}
+
+	// the first page starts before any account
+	page1, err := testQueries.ListAccountsAfter(context.Background(), ListAccountsAfterParams{
+		Owner: user.Username,
+		Limit: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, page1, 2)
+
+	// the next page starts right after the last account of the first page
+	last := page1[len(page1)-1]
+	page2, err := testQueries.ListAccountsAfter(context.Background(), ListAccountsAfterParams{
+		Owner:          user.Username,
+		AfterCreatedAt: last.CreatedAt,
+		AfterID:        last.ID,
+		Limit:          2,
+	})
+	require.NoError(t, err)
+	require.Len(t, page2, 1)
+
+	// the accounts are sorted and no account is repeated
+	accounts := append(page1, page2...)
+	for i := 1; i < len(accounts); i++ {
+		require.Equal(t, user.Username, accounts[i].Owner)
+		require.False(t, accounts[i].CreatedAt.Before(accounts[i-1].CreatedAt))
+		require.NotEqual(t, accounts[i-1].ID, accounts[i].ID)
 	}
 }
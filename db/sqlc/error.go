@@ -0,0 +1,151 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+)
+
+// ErrInsufficientFunds is returned by TransferTx when the sender does not have enough available money,
+// including its overdraft limit. The money reserved by the holds of the account is not available
+// The whole transaction is rolled back, so no transfer, journal entry or balance update is saved
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// ErrOverdraftLimitTooLow is returned by SetOverdraftLimit when the account is already
+// more overdrawn than the new limit allows
+var ErrOverdraftLimitTooLow = errors.New("overdraft limit is lower than the current overdraft")
+
+// ErrAccountNotActive is returned by TransferTx when one of the accounts is frozen or closed
+var ErrAccountNotActive = errors.New("account is not active")
+
+// ErrAccountClosed is returned by SetAccountStatus when the account is already closed
+// closing an account is final, so its status can no longer change
+var ErrAccountClosed = errors.New("account is closed")
+
+// ErrAccountBalanceNotZero is returned by SetAccountStatus when an account that still has money,
+// or still owes money, or still has active holds, is being closed
+var ErrAccountBalanceNotZero = errors.New("account balance must be zero to close it")
+
+// ErrIdempotencyKeyExists is returned when the idempotency key of a request has already been used by the same user
+// The transaction is rolled back, the caller is expected to look up the stored response instead
+var ErrIdempotencyKeyExists = errors.New("idempotency key already exists")
+
+// ErrUnbalancedJournalEntry is returned when the postings of a journal entry don't net to zero in each currency
+// It means that money would be created or lost, so nothing is written
+var ErrUnbalancedJournalEntry = errors.New("journal entry does not balance")
+
+// ErrLimitExceeded is returned by TransferTx when the transfer would go beyond one of the velocity limits of the sender
+// It is always wrapped in a *LimitExceededError, which tells which limit it is, so use errors.Is or errors.As
+var ErrLimitExceeded = errors.New("transfer limit exceeded")
+
+// ErrPendingTransferNotPending is returned when a pending transfer is approved or rejected after it was closed:
+// rejected, executed or expired. A transfer that is already approved can only be approved again, to retry its execution
+var ErrPendingTransferNotPending = errors.New("pending transfer is no longer pending")
+
+// ErrPendingTransferExpired is returned when a pending transfer is reviewed after its expiry
+// The pending transfer is marked as expired, so it can no longer be executed
+var ErrPendingTransferExpired = errors.New("pending transfer has expired")
+
+// ErrSelfReview is returned when a user approves or rejects a transfer that he requested himself
+// A transfer above the approval threshold always needs a second person
+var ErrSelfReview = errors.New("a transfer cannot be reviewed by the user who requested it")
+
+// ErrStandingOrderClosed is returned when a standing order is changed after it was cancelled or completed
+var ErrStandingOrderClosed = errors.New("standing order is closed")
+
+// ErrHoldNotActive is returned when a hold is captured or released after it was fully captured, released or expired
+var ErrHoldNotActive = errors.New("hold is no longer active")
+
+// ErrHoldExpired is returned when a hold is captured after its expiry
+// The hold is not changed, the scheduler releases its money with the other expired holds
+var ErrHoldExpired = errors.New("hold has expired")
+
+// ErrHoldAmountExceeded is returned when a capture is larger than what is left of the hold
+var ErrHoldAmountExceeded = errors.New("capture exceeds the remaining amount of the hold")
+
+// ErrHoldRequiresApproval is returned by CreateHoldTx when the amount is above the approval threshold of its currency
+// A hold cannot wait for a banker, such a payment must be made with a transfer instead
+var ErrHoldRequiresApproval = errors.New("hold amount is above the approval threshold")
+
+// These are the velocity limits of an account, they are the names of the columns of the limit tables
+const (
+	MaxAmountLimit      = "max_amount"       // the amount of a single transfer
+	MaxDailyAmountLimit = "max_daily_amount" // the total of the transfers sent in the last 24 hours
+	MaxDailyCountLimit  = "max_daily_count"  // the number of transfers sent in the last 24 hours
+)
+
+// LimitExceededError gives the details of an ErrLimitExceeded, they are sent to the client as JSON
+// The amounts are in the minor unit of the currency of the account
+type LimitExceededError struct {
+	AccountID int64  `json:"account_id"`
+	Limit     string `json:"limit"`     // one of the limits above
+	Max       int64  `json:"max"`       // the value of the limit
+	Used      int64  `json:"used"`      // what the transfers of the last 24 hours already used, 0 for max_amount
+	Requested int64  `json:"requested"` // what this transfer would add: its amount, or 1 for max_daily_count
+}
+
+func (err *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s: %s of account [%d] is %d, %d already used and %d requested",
+		ErrLimitExceeded, err.Limit, err.AccountID, err.Max, err.Used, err.Requested)
+}
+
+// Unwrap makes errors.Is(err, ErrLimitExceeded) true
+func (err *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// this is the name of the CHECK constraint on accounts.balance
+// it is defined in the migrations
+const balanceConstraint = "balance_within_overdraft_limit"
+
+// this is the name of the CHECK constraint that only allows closing an account with a zero balance
+const closedAccountConstraint = "closed_account_zero_balance"
+
+// this is the name of the constraint trigger that checks that the postings of a journal entry net to zero
+const journalEntryBalancedConstraint = "journal_entry_balanced"
+
+// this is the name of the primary key of the idempotency_keys table
+const idempotencyKeyConstraint = "idempotency_keys_pkey"
+
+// isConstraintViolation checks if the error returned by postgres is the violation of a specific CHECK constraint
+func isConstraintViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Name() == "check_violation" && pqErr.Constraint == constraint
+	}
+	return false
+}
+
+// isUniqueViolation checks if the error returned by postgres is the violation of a specific UNIQUE constraint
+// a primary key is also a unique constraint
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Name() == "unique_violation" && pqErr.Constraint == constraint
+	}
+	return false
+}
+
+// isSerializationFailure checks if postgres aborted the transaction because it could not be serialized (SQLSTATE 40001)
+func isSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Name() == "serialization_failure"
+	}
+	return false
+}
+
+// isDeadlock checks if postgres aborted the transaction to break a deadlock (SQLSTATE 40P01)
+func isDeadlock(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Name() == "deadlock_detected"
+	}
+	return false
+}
+
+// isRetryableTxError checks if the transaction failed only because of a concurrent transaction
+// in that case, nothing was saved and the transaction can safely be run again
+func isRetryableTxError(err error) bool {
+	return isSerializationFailure(err) || isDeadlock(err)
+}
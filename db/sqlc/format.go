@@ -0,0 +1,259 @@
+package db
+
+import (
+	"encoding/json"
+	"github.com/elmas23/simplebank/money"
+)
+
+// The amounts are saved as integers in the minor unit of their currency, like cents
+// When an account, a posting, a transfer, a pending transfer, a standing order or a hold is sent as JSON, its amounts are sent
+// as decimal strings with the minor units of their currency, like "12.34" for 1234 USD, so the clients don't have to know them
+// A hold capture has no currency of its own, so its amount is sent in minor units, in the currency of its hold
+// These methods are not generated by sqlc, so they are kept when the models are generated again
+
+// MarshalJSON sends the balance, the held amount and the overdraft limit of an account as decimal strings
+// The available balance is not saved, it is computed here so the clients don't have to: it is the balance
+// without what the active holds reserve, and it is what the account can still transfer or withdraw, with its overdraft limit
+func (account Account) MarshalJSON() ([]byte, error) {
+	type accountJSON Account // this type has no MarshalJSON method, so it doesn't call this one again
+	return json.Marshal(struct {
+		accountJSON
+		// these fields hide the ones of accountJSON, since they are less deep
+		Balance          money.Money `json:"balance"`
+		HeldAmount       money.Money `json:"held_amount"`
+		AvailableBalance money.Money `json:"available_balance"`
+		OverdraftLimit   money.Money `json:"overdraft_limit"`
+	}{
+		accountJSON:      accountJSON(account),
+		Balance:          money.New(account.Balance, account.Currency),
+		HeldAmount:       money.New(account.HeldAmount, account.Currency),
+		AvailableBalance: money.New(account.Balance-account.HeldAmount, account.Currency),
+		OverdraftLimit:   money.New(account.OverdraftLimit, account.Currency),
+	})
+}
+
+// UnmarshalJSON reads an account sent by MarshalJSON
+func (account *Account) UnmarshalJSON(data []byte) error {
+	type accountJSON Account
+	var value struct {
+		accountJSON
+		Balance        string `json:"balance"`
+		HeldAmount     string `json:"held_amount"`
+		OverdraftLimit string `json:"overdraft_limit"`
+		// the available balance is ignored, it is computed from the balance and the held amount
+		AvailableBalance string `json:"available_balance"`
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	balance, err := money.Parse(value.Balance, value.Currency)
+	if err != nil {
+		return err
+	}
+	heldAmount, err := money.Parse(value.HeldAmount, value.Currency)
+	if err != nil {
+		return err
+	}
+	overdraftLimit, err := money.Parse(value.OverdraftLimit, value.Currency)
+	if err != nil {
+		return err
+	}
+
+	*account = Account(value.accountJSON)
+	account.Balance = balance.Amount
+	account.HeldAmount = heldAmount.Amount
+	account.OverdraftLimit = overdraftLimit.Amount
+	return nil
+}
+
+// MarshalJSON sends the amount of a posting as a decimal string in the currency of the posting
+// A debit is negative, like "-12.34"
+func (posting Posting) MarshalJSON() ([]byte, error) {
+	type postingJSON Posting
+	return json.Marshal(struct {
+		postingJSON
+		Amount money.Money `json:"amount"`
+	}{
+		postingJSON: postingJSON(posting),
+		Amount:      money.New(posting.Amount, posting.Currency),
+	})
+}
+
+// UnmarshalJSON reads a posting sent by MarshalJSON
+func (posting *Posting) UnmarshalJSON(data []byte) error {
+	type postingJSON Posting
+	var value struct {
+		postingJSON
+		Amount string `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	amount, err := money.Parse(value.Amount, value.Currency)
+	if err != nil {
+		return err
+	}
+
+	*posting = Posting(value.postingJSON)
+	posting.Amount = amount.Amount
+	return nil
+}
+
+// MarshalJSON sends the amounts of a transfer as decimal strings
+// the amount and the fee are in the currency of the sender, and the credited amount in the currency of the receiver
+func (transfer Transfer) MarshalJSON() ([]byte, error) {
+	type transferJSON Transfer
+	return json.Marshal(struct {
+		transferJSON
+		Amount         money.Money `json:"amount"`
+		CreditedAmount money.Money `json:"credited_amount"`
+		Fee            money.Money `json:"fee"`
+	}{
+		transferJSON:   transferJSON(transfer),
+		Amount:         money.New(transfer.Amount, transfer.FromCurrency),
+		CreditedAmount: money.New(transfer.CreditedAmount, transfer.ToCurrency),
+		Fee:            money.New(transfer.Fee, transfer.FromCurrency),
+	})
+}
+
+// UnmarshalJSON reads a transfer sent by MarshalJSON
+func (transfer *Transfer) UnmarshalJSON(data []byte) error {
+	type transferJSON Transfer
+	var value struct {
+		transferJSON
+		Amount         string `json:"amount"`
+		CreditedAmount string `json:"credited_amount"`
+		Fee            string `json:"fee"`
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	amount, err := money.Parse(value.Amount, value.FromCurrency)
+	if err != nil {
+		return err
+	}
+	creditedAmount, err := money.Parse(value.CreditedAmount, value.ToCurrency)
+	if err != nil {
+		return err
+	}
+	fee, err := money.Parse(value.Fee, value.FromCurrency)
+	if err != nil {
+		return err
+	}
+
+	*transfer = Transfer(value.transferJSON)
+	transfer.Amount = amount.Amount
+	transfer.CreditedAmount = creditedAmount.Amount
+	transfer.Fee = fee.Amount
+	return nil
+}
+
+// MarshalJSON sends the amount of a pending transfer as a decimal string, like the amount of a transfer
+func (pending PendingTransfer) MarshalJSON() ([]byte, error) {
+	type pendingTransferJSON PendingTransfer
+	return json.Marshal(struct {
+		pendingTransferJSON
+		Amount money.Money `json:"amount"`
+	}{
+		pendingTransferJSON: pendingTransferJSON(pending),
+		Amount:              money.New(pending.Amount, pending.Currency),
+	})
+}
+
+// UnmarshalJSON reads a pending transfer sent by MarshalJSON
+func (pending *PendingTransfer) UnmarshalJSON(data []byte) error {
+	type pendingTransferJSON PendingTransfer
+	var value struct {
+		pendingTransferJSON
+		Amount string `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	amount, err := money.Parse(value.Amount, value.Currency)
+	if err != nil {
+		return err
+	}
+
+	*pending = PendingTransfer(value.pendingTransferJSON)
+	pending.Amount = amount.Amount
+	return nil
+}
+
+// MarshalJSON sends the amount of a standing order as a decimal string, like the amount of a transfer
+func (order StandingOrder) MarshalJSON() ([]byte, error) {
+	type standingOrderJSON StandingOrder
+	return json.Marshal(struct {
+		standingOrderJSON
+		Amount money.Money `json:"amount"`
+	}{
+		standingOrderJSON: standingOrderJSON(order),
+		Amount:            money.New(order.Amount, order.Currency),
+	})
+}
+
+// UnmarshalJSON reads a standing order sent by MarshalJSON
+func (order *StandingOrder) UnmarshalJSON(data []byte) error {
+	type standingOrderJSON StandingOrder
+	var value struct {
+		standingOrderJSON
+		Amount string `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	amount, err := money.Parse(value.Amount, value.Currency)
+	if err != nil {
+		return err
+	}
+
+	*order = StandingOrder(value.standingOrderJSON)
+	order.Amount = amount.Amount
+	return nil
+}
+
+// MarshalJSON sends the amount of a hold and what was captured of it as decimal strings, in the currency of the account
+func (hold Hold) MarshalJSON() ([]byte, error) {
+	type holdJSON Hold
+	return json.Marshal(struct {
+		holdJSON
+		Amount         money.Money `json:"amount"`
+		CapturedAmount money.Money `json:"captured_amount"`
+	}{
+		holdJSON:       holdJSON(hold),
+		Amount:         money.New(hold.Amount, hold.Currency),
+		CapturedAmount: money.New(hold.CapturedAmount, hold.Currency),
+	})
+}
+
+// UnmarshalJSON reads a hold sent by MarshalJSON
+func (hold *Hold) UnmarshalJSON(data []byte) error {
+	type holdJSON Hold
+	var value struct {
+		holdJSON
+		Amount         string `json:"amount"`
+		CapturedAmount string `json:"captured_amount"`
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	amount, err := money.Parse(value.Amount, value.Currency)
+	if err != nil {
+		return err
+	}
+	capturedAmount, err := money.Parse(value.CapturedAmount, value.Currency)
+	if err != nil {
+		return err
+	}
+
+	*hold = Hold(value.holdJSON)
+	hold.Amount = amount.Amount
+	hold.CapturedAmount = capturedAmount.Amount
+	return nil
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAccountJSON(t *testing.T) {
+	account := Account{ID: 1, Owner: "owner", Balance: -1234, Currency: "USD", OverdraftLimit: 5000, HeldAmount: 766}
+
+	data, err := json.Marshal(account)
+	require.NoError(t, err)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &body))
+	require.Equal(t, "-12.34", body["balance"])
+	require.Equal(t, "7.66", body["held_amount"])
+	require.Equal(t, "-20.00", body["available_balance"])
+	require.Equal(t, "50.00", body["overdraft_limit"])
+	require.Equal(t, "USD", body["currency"])
+
+	var decoded Account
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, account, decoded)
+}
+
+func TestPostingJSON(t *testing.T) {
+	posting := Posting{ID: 1, AccountID: 2, Amount: -1234, JournalEntryID: 3, Currency: "USD"}
+
+	data, err := json.Marshal(posting)
+	require.NoError(t, err)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &body))
+	require.Equal(t, "-12.34", body["amount"])
+
+	var decoded Posting
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, posting, decoded)
+}
+
+func TestTransferJSON(t *testing.T) {
+	transfer := Transfer{ID: 1, Amount: 1000, FromCurrency: "USD", CreditedAmount: 1500, ToCurrency: "JPY", ExchangeRate: "150.0000000000", Fee: 25}
+
+	data, err := json.Marshal(transfer)
+	require.NoError(t, err)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &body))
+	require.Equal(t, "10.00", body["amount"])
+	require.Equal(t, "1500", body["credited_amount"])
+	require.Equal(t, "0.25", body["fee"]) // the fee is in the currency of the sender
+
+	var decoded Transfer
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, transfer, decoded)
+
+	// an amount with more decimals than its currency is refused
+	err = json.Unmarshal([]byte(`{"amount": "10.001", "from_currency": "USD", "credited_amount": "1", "to_currency": "JPY"}`), &decoded)
+	require.Error(t, err)
+}
+
+func TestPendingTransferJSON(t *testing.T) {
+	reviewer := "banker"
+	pending := PendingTransfer{ID: 1, Amount: 1234567, Currency: "EUR", Status: "approved", RequestedBy: "owner", ReviewedBy: &reviewer}
+
+	data, err := json.Marshal(pending)
+	require.NoError(t, err)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &body))
+	require.Equal(t, "12345.67", body["amount"])
+	require.Equal(t, "banker", body["reviewed_by"])
+	require.Nil(t, body["transfer_id"]) // it is not executed yet
+
+	var decoded PendingTransfer
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, pending, decoded)
+}
+
+func TestStandingOrderJSON(t *testing.T) {
+	order := StandingOrder{ID: 1, Amount: 150000, Currency: "USD", Frequency: "monthly", IntervalCount: 1, Status: "active"}
+
+	data, err := json.Marshal(order)
+	require.NoError(t, err)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &body))
+	require.Equal(t, "1500.00", body["amount"])
+	require.Nil(t, body["end_at"]) // it never ends
+
+	var decoded StandingOrder
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, order, decoded)
+}
+
+func TestHoldJSON(t *testing.T) {
+	hold := Hold{ID: 1, AccountID: 2, ToAccountID: 3, Amount: 5000, CapturedAmount: 1250, Currency: "USD", Status: "active"}
+
+	data, err := json.Marshal(hold)
+	require.NoError(t, err)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &body))
+	require.Equal(t, "50.00", body["amount"])
+	require.Equal(t, "12.50", body["captured_amount"])
+
+	var decoded Hold
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, hold, decoded)
+}
@@ -0,0 +1,287 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/money"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// createRandomHold reserves amount on the from account for the to account
+func createRandomHold(t *testing.T, store Store, from Account, to Account, amount money.Amount) Hold {
+	hold, err := store.CreateHoldTx(context.Background(), CreateHoldTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        money.New(amount, from.Currency),
+		CreatedBy:     from.Owner,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, hold.ID)
+	require.Equal(t, from.ID, hold.AccountID)
+	require.Equal(t, to.ID, hold.ToAccountID)
+	require.Equal(t, amount, hold.Amount)
+	require.Zero(t, hold.CapturedAmount)
+	require.Equal(t, utils.ActiveHoldStatus, hold.Status)
+	require.Equal(t, from.Owner, hold.CreatedBy)
+	return hold
+}
+
+func TestCreateHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createFundedAccount(t, 0)
+
+	hold := createRandomHold(t, store, account1, account2, 60)
+	require.WithinDuration(t, time.Now().Add(defaultHoldExpiry), hold.ExpiresAt, time.Minute)
+
+	// the money stays on the account, it is only reserved
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(100), updatedAccount1.Balance)
+	require.Equal(t, money.Amount(60), updatedAccount1.HeldAmount)
+
+	// a second hold cannot reserve more than the available balance
+	_, err = store.CreateHoldTx(context.Background(), CreateHoldTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(41, "USD"),
+		CreatedBy:     account1.Owner,
+	})
+	require.True(t, errors.Is(err, ErrInsufficientFunds))
+
+	// neither can a transfer or a withdrawal
+	banker := createRandomUser(t)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(41, "USD"),
+	})
+	require.True(t, errors.Is(err, ErrInsufficientFunds))
+
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account1.ID,
+		Amount:    money.New(41, "USD"),
+		CreatedBy: banker.Username,
+	})
+	require.True(t, errors.Is(err, ErrInsufficientFunds))
+
+	// what is not reserved can still be spent
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account1.ID,
+		Amount:    money.New(40, "USD"),
+		CreatedBy: banker.Username,
+	})
+	require.NoError(t, err)
+
+	report, err := store.ReconcileLedger(context.Background())
+	require.NoError(t, err)
+	requireNoHoldDiscrepancy(t, report, account1.ID)
+}
+
+func TestCaptureHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createFundedAccount(t, 0)
+	hold := createRandomHold(t, store, account1, account2, 60)
+
+	// a partial capture keeps the hold active
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID, Amount: 20})
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(20), result.Transfer.Amount)
+	require.Equal(t, account1.ID, result.Transfer.FromAccountID)
+	require.Equal(t, account2.ID, result.Transfer.ToAccountID)
+	require.NotNil(t, result.Hold)
+	require.Equal(t, money.Amount(20), result.Hold.CapturedAmount)
+	require.Equal(t, utils.ActiveHoldStatus, result.Hold.Status)
+	require.Equal(t, money.Amount(80), result.FromAccount.Balance)
+	require.Equal(t, money.Amount(40), result.FromAccount.HeldAmount)
+	require.Equal(t, money.Amount(20), result.ToAccount.Balance)
+
+	// more than what is left cannot be captured
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID, Amount: 41})
+	require.True(t, errors.Is(err, ErrHoldAmountExceeded))
+
+	// without an amount, what is left is captured and the hold is closed
+	result, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID})
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(40), result.Transfer.Amount)
+	require.Equal(t, money.Amount(60), result.Hold.CapturedAmount)
+	require.Equal(t, utils.CapturedHoldStatus, result.Hold.Status)
+	require.Equal(t, money.Amount(40), result.FromAccount.Balance)
+	require.Zero(t, result.FromAccount.HeldAmount)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID})
+	require.True(t, errors.Is(err, ErrHoldNotActive))
+
+	captures, err := testQueries.ListHoldCaptures(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Len(t, captures, 2)
+	require.Equal(t, money.Amount(20), captures[0].Amount) // oldest first
+	require.Equal(t, money.Amount(40), captures[1].Amount)
+	require.Equal(t, result.Transfer.ID, captures[1].TransferID)
+
+	report, err := store.ReconcileLedger(context.Background())
+	require.NoError(t, err)
+	requireNoBalanceDiscrepancy(t, report, account1.ID)
+	requireNoHoldDiscrepancy(t, report, account1.ID)
+}
+
+func TestCaptureHoldTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createFundedAccount(t, 0)
+	hold := createRandomHold(t, store, account1, account2, 50)
+
+	// the receiver sends the same capture several times, only what the hold reserves is captured
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID, Amount: 20})
+			errs <- err
+		}()
+	}
+
+	captured := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if errors.Is(err, ErrHoldAmountExceeded) {
+			continue
+		}
+		require.NoError(t, err)
+		captured++
+	}
+	require.Equal(t, 2, captured)
+
+	updatedHold, err := testQueries.GetHold(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(40), updatedHold.CapturedAmount)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(60), updatedAccount1.Balance)
+	require.Equal(t, money.Amount(10), updatedAccount1.HeldAmount)
+}
+
+func TestReleaseHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createFundedAccount(t, 0)
+	hold := createRandomHold(t, store, account1, account2, 60)
+
+	_, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID, Amount: 10})
+	require.NoError(t, err)
+
+	released, err := store.ReleaseHoldTx(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, utils.ReleasedHoldStatus, released.Status)
+	require.Equal(t, money.Amount(10), released.CapturedAmount)
+
+	// what was not captured is available again
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(90), updatedAccount1.Balance)
+	require.Zero(t, updatedAccount1.HeldAmount)
+
+	_, err = store.ReleaseHoldTx(context.Background(), hold.ID)
+	require.True(t, errors.Is(err, ErrHoldNotActive))
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID})
+	require.True(t, errors.Is(err, ErrHoldNotActive))
+}
+
+func TestExpireDueHoldTx(t *testing.T) {
+	// the holds of this store expire right away
+	store := NewStore(testDB, WithHoldExpiry(time.Millisecond))
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createFundedAccount(t, 0)
+	hold := createRandomHold(t, store, account1, account2, 60)
+
+	now := time.Now().Add(time.Second)
+
+	// an expired hold cannot be captured, even before the scheduler expires it
+	_, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID})
+	require.True(t, errors.Is(err, ErrHoldExpired))
+
+	// other tests may have left expired holds, they are all expired
+	expired := false
+	for {
+		result, err := store.ExpireDueHoldTx(context.Background(), now)
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, utils.ExpiredHoldStatus, result.Status)
+		if result.ID == hold.ID {
+			expired = true
+		}
+	}
+	require.True(t, expired)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(100), updatedAccount1.Balance)
+	require.Zero(t, updatedAccount1.HeldAmount)
+
+	_, err = store.ReleaseHoldTx(context.Background(), hold.ID)
+	require.True(t, errors.Is(err, ErrHoldNotActive))
+}
+
+func TestListHolds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createFundedAccount(t, 0)
+	for i := 0; i < 3; i++ {
+		createRandomHold(t, store, account1, account2, 10)
+	}
+
+	holds, err := testQueries.ListHolds(context.Background(), ListHoldsParams{AccountID: account1.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, holds, 3)
+
+	_, err = store.ReleaseHoldTx(context.Background(), holds[0].ID)
+	require.NoError(t, err)
+
+	// only the holds with the status are listed
+	holds, err = testQueries.ListHolds(context.Background(), ListHoldsParams{
+		AccountID: account1.ID,
+		Status:    sql.NullString{String: utils.ActiveHoldStatus, Valid: true},
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, holds, 2)
+
+	// the next page starts after the last hold
+	last := holds[0]
+	holds, err = testQueries.ListHolds(context.Background(), ListHoldsParams{
+		AccountID:      account1.ID,
+		Status:         sql.NullString{String: utils.ActiveHoldStatus, Valid: true},
+		AfterCreatedAt: last.CreatedAt,
+		AfterID:        last.ID,
+		Limit:          10,
+	})
+	require.NoError(t, err)
+	require.Len(t, holds, 1)
+
+	// the receiver doesn't see them
+	holds, err = testQueries.ListHolds(context.Background(), ListHoldsParams{AccountID: account2.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, holds)
+}
+
+// requireNoHoldDiscrepancy checks that the held amount of the account is what its active holds reserve
+func requireNoHoldDiscrepancy(t *testing.T, report ReconciliationReport, accountID int64) {
+	for _, discrepancy := range report.HoldDiscrepancies {
+		require.NotEqual(t, accountID, discrepancy.AccountID)
+	}
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func createRandomIdempotencyKey(t *testing.T, user User) IdempotencyKey {
+	arg := CreateIdempotencyKeyParams{
+		Username:    user.Username,
+		Key:         utils.GenerateRandomString(16),
+		RequestHash: utils.GenerateRandomString(64),
+	}
+
+	idempotencyKey, err := testQueries.CreateIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, idempotencyKey)
+
+	require.Equal(t, arg.Username, idempotencyKey.Username)
+	require.Equal(t, arg.Key, idempotencyKey.Key)
+	require.Equal(t, arg.RequestHash, idempotencyKey.RequestHash)
+	// the response is only saved once the request has been processed
+	require.Zero(t, idempotencyKey.ResponseStatus)
+	require.Empty(t, idempotencyKey.ResponseBody)
+	require.NotZero(t, idempotencyKey.CreatedAt)
+
+	return idempotencyKey
+}
+
+func TestCreateIdempotencyKey(t *testing.T) {
+	user := createRandomUser(t)
+	idempotencyKey := createRandomIdempotencyKey(t, user)
+
+	// the same user cannot use the same key twice
+	_, err := testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Username:    user.Username,
+		Key:         idempotencyKey.Key,
+		RequestHash: idempotencyKey.RequestHash,
+	})
+	require.True(t, isUniqueViolation(err, idempotencyKeyConstraint))
+
+	// but another user can
+	otherUser := createRandomUser(t)
+	_, err = testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Username:    otherUser.Username,
+		Key:         idempotencyKey.Key,
+		RequestHash: idempotencyKey.RequestHash,
+	})
+	require.NoError(t, err)
+}
+
+func TestUpdateIdempotencyKeyResponse(t *testing.T) {
+	user := createRandomUser(t)
+	idempotencyKey1 := createRandomIdempotencyKey(t, user)
+
+	arg := UpdateIdempotencyKeyResponseParams{
+		Username:       idempotencyKey1.Username,
+		Key:            idempotencyKey1.Key,
+		ResponseStatus: 200,
+		ResponseBody:   []byte(`{"id":1}`),
+	}
+
+	idempotencyKey2, err := testQueries.UpdateIdempotencyKeyResponse(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, idempotencyKey1.RequestHash, idempotencyKey2.RequestHash)
+	require.Equal(t, arg.ResponseStatus, idempotencyKey2.ResponseStatus)
+	require.Equal(t, arg.ResponseBody, idempotencyKey2.ResponseBody)
+
+	idempotencyKey3, err := testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: user.Username,
+		Key:      idempotencyKey1.Key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, idempotencyKey2, idempotencyKey3)
+}
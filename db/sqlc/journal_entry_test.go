@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/money"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGetJournalEntry(t *testing.T) {
+	account := createRandomAccount(t)
+	posting := createRandomPosting(t, account)
+
+	journalEntry, err := testQueries.GetJournalEntry(context.Background(), posting.JournalEntryID)
+	require.NoError(t, err)
+	require.Equal(t, posting.JournalEntryID, journalEntry.ID)
+	require.Equal(t, utils.AdjustmentEntryKind, journalEntry.Kind)
+	require.Nil(t, journalEntry.TransferID) // this journal entry doesn't come from a transfer
+	require.NotZero(t, journalEntry.CreatedAt)
+}
+
+func TestGetTransferJournalEntry(t *testing.T) {
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccountWithBalance(t, 100)
+
+	result, err := NewStore(testDB).TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(10, "USD"),
+	})
+	require.NoError(t, err)
+
+	journalEntry, err := testQueries.GetTransferJournalEntry(context.Background(), result.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.JournalEntry, journalEntry)
+
+	_, err = testQueries.GetTransferJournalEntry(context.Background(), result.Transfer.ID+1000000)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestPostJournalEntryUnbalanced(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testDB).(*SQLStore)
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccountWithBalance(t, 100)
+
+	testCases := []struct {
+		name  string
+		lines []PostingLine
+	}{
+		{
+			name: "NotZero",
+			lines: []PostingLine{
+				{AccountID: account1.ID, Amount: money.New(-10, "USD")},
+				{AccountID: account2.ID, Amount: money.New(9, "USD")},
+			},
+		},
+		{
+			name: "SinglePosting",
+			lines: []PostingLine{
+				{AccountID: account1.ID, Amount: money.New(10, "USD")},
+			},
+		},
+		{
+			// the total of all the currencies is zero, but each currency must be zero on its own
+			name: "OtherCurrency",
+			lines: []PostingLine{
+				{AccountID: account1.ID, Amount: money.New(-10, "USD")},
+				{AccountID: account2.ID, Amount: money.New(10, "EUR")},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := store.execTx(ctx, nil, func(q *Queries) error {
+				_, err := postJournalEntry(ctx, q, JournalEntryParams{
+					Kind:  utils.AdjustmentEntryKind,
+					Lines: tc.lines,
+				})
+				return err
+			})
+			require.ErrorIs(t, err, ErrUnbalancedJournalEntry)
+		})
+	}
+
+	// nothing was written
+	updatedAccount1, err := store.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	postings, err := store.ListPostings(ctx, ListPostingsParams{AccountID: account1.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Empty(t, postings)
+}
+
+func TestJournalEntryBalancedConstraint(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccountWithBalance(t, 100)
+
+	// the postings are written without postJournalEntry, like a bug or a query run by hand would do
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	q := New(tx)
+
+	journalEntry, err := q.CreateJournalEntry(ctx, CreateJournalEntryParams{Kind: utils.AdjustmentEntryKind})
+	require.NoError(t, err)
+
+	// the check is deferred, so the single posting is accepted until the commit
+	_, err = q.CreatePosting(ctx, CreatePostingParams{
+		JournalEntryID: journalEntry.ID,
+		AccountID:      account.ID,
+		Currency:       account.Currency,
+		Amount:         10,
+	})
+	require.NoError(t, err)
+
+	err = tx.Commit()
+	require.Error(t, err)
+	require.True(t, isConstraintViolation(err, journalEntryBalancedConstraint))
+
+	_, err = testQueries.GetJournalEntry(ctx, journalEntry.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestPostingCurrencyConstraint(t *testing.T) {
+	ctx := context.Background()
+	account1 := createRandomAccountWithCurrency(t, 100, "USD")
+	account2 := createRandomAccountWithCurrency(t, 100, "EUR")
+
+	// the postings net to zero, but the second one is not in the currency of its account
+	err := NewStore(testDB).(*SQLStore).execTx(ctx, nil, func(q *Queries) error {
+		_, err := postJournalEntry(ctx, q, JournalEntryParams{
+			Kind: utils.AdjustmentEntryKind,
+			Lines: []PostingLine{
+				{AccountID: account1.ID, Amount: money.New(-10, "USD")},
+				{AccountID: account2.ID, Amount: money.New(10, "USD")},
+			},
+		})
+		return err
+	})
+	require.Error(t, err)
+
+	updatedAccount1, err := testQueries.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestSystemAccount(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testDB).(*SQLStore)
+
+	var account1, account2 Account
+	err := store.execTx(ctx, readCommittedTx, func(q *Queries) error {
+		var err error
+		if account1, err = systemAccount(ctx, q, utils.FeesAccountOwner, "USD"); err != nil {
+			return err
+		}
+		// the second call finds the account created by the first one
+		account2, err = systemAccount(ctx, q, utils.FeesAccountOwner, "USD")
+		return err
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account1, account2)
+	require.Equal(t, utils.FeesAccountOwner, account1.Owner)
+	require.Equal(t, "USD", account1.Currency)
+	require.Equal(t, utils.ActiveStatus, account1.Status)
+	require.Equal(t, money.Amount(9223372036854775807), account1.OverdraftLimit)
+}
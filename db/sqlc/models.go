@@ -6,22 +6,174 @@ package db
 
 import (
 	"time"
+
+	"github.com/elmas23/simplebank/money"
+	"github.com/google/uuid"
 )
 
 type Account struct {
-	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
-	Balance   int64     `json:"balance"`
-	Currency  string    `json:"currency"`
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	// balance - held_amount cannot go below -overdraft_limit
+	Balance   money.Amount `json:"balance"`
+	Currency  string       `json:"currency"`
+	CreatedAt time.Time    `json:"created_at"`
+	// cannot be negative
+	OverdraftLimit money.Amount `json:"overdraft_limit"`
+	// active, frozen or closed
+	Status string `json:"status"`
+	// the sum of what the active holds of the account have not captured yet
+	HeldAmount money.Amount `json:"held_amount"`
+}
+
+type AccountTransferLimit struct {
+	AccountID      int64         `json:"account_id"`
+	MaxAmount      *money.Amount `json:"max_amount"`
+	MaxDailyAmount *money.Amount `json:"max_daily_amount"`
+	MaxDailyCount  *int64        `json:"max_daily_count"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+type CurrencyTransferLimit struct {
+	Currency          string        `json:"currency"`
+	MaxAmount         *money.Amount `json:"max_amount"`
+	MaxDailyAmount    *money.Amount `json:"max_daily_amount"`
+	MaxDailyCount     *int64        `json:"max_daily_count"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+	ApprovalThreshold *money.Amount `json:"approval_threshold"`
+}
+
+type Hold struct {
+	ID          int64 `json:"id"`
+	AccountID   int64 `json:"account_id"`
+	ToAccountID int64 `json:"to_account_id"`
+	// in the currency of the account, without the fee
+	Amount         money.Amount `json:"amount"`
+	CapturedAmount money.Amount `json:"captured_amount"`
+	Currency       string       `json:"currency"`
+	// active, captured, released or expired
+	Status    string    `json:"status"`
+	CreatedBy string    `json:"created_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type HoldCapture struct {
+	ID         int64        `json:"id"`
+	HoldID     int64        `json:"hold_id"`
+	TransferID int64        `json:"transfer_id"`
+	Amount     money.Amount `json:"amount"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	Username string `json:"username"`
+	Key      string `json:"key"`
+	// a replay with a different request is rejected
+	RequestHash    string    `json:"request_hash"`
+	ResponseStatus int32     `json:"response_status"`
+	ResponseBody   []byte    `json:"response_body"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type JournalEntry struct {
+	ID int64 `json:"id"`
+	// the business event: transfer, migration...
+	Kind string `json:"kind"`
+	// a transfer has exactly one journal entry
+	TransferID *int64    `json:"transfer_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	// the banker who made a deposit or a withdrawal, NULL for the other kinds
+	CreatedBy *string `json:"created_by"`
+}
+
+type PendingTransfer struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	// in the currency of the sender, without the fee
+	Amount   money.Amount `json:"amount"`
+	Currency string       `json:"currency"`
+	// pending, approved, rejected, executed or expired
+	Status      string     `json:"status"`
+	RequestedBy string     `json:"requested_by"`
+	ReviewedBy  *string    `json:"reviewed_by"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	TransferID  *int64     `json:"transfer_id"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type PendingTransferEvent struct {
+	ID                int64  `json:"id"`
+	PendingTransferID int64  `json:"pending_transfer_id"`
+	Action            string `json:"action"`
+	// NULL when nobody did it, like an expiry
+	Actor  *string `json:"actor"`
+	Status string  `json:"status"`
+	// the reason of a rejection, or the error of a failed execution
+	Note      string    `json:"note"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
-type Entry struct {
+type Posting struct {
 	ID        int64 `json:"id"`
 	AccountID int64 `json:"account_id"`
 	// can be negative or positive
-	Amount    int64     `json:"amount"`
+	Amount         money.Amount `json:"amount"`
+	CreatedAt      time.Time    `json:"created_at"`
+	JournalEntryID int64        `json:"journal_entry_id"`
+	Currency       string       `json:"currency"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type StandingOrder struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	// in the currency of the sender, without the fee
+	Amount         money.Amount `json:"amount"`
+	Currency       string       `json:"currency"`
+	Frequency      string       `json:"frequency"`
+	IntervalCount  int32        `json:"interval_count"`
+	StartAt        time.Time    `json:"start_at"`
+	EndAt          *time.Time   `json:"end_at"`
+	NextRunAt      time.Time    `json:"next_run_at"`
+	RunCount       int64        `json:"run_count"`
+	FailedAttempts int32        `json:"failed_attempts"`
+	RetryAt        *time.Time   `json:"retry_at"`
+	// active, paused, cancelled or completed
+	Status    string    `json:"status"`
+	CreatedBy string    `json:"created_by"`
 	CreatedAt time.Time `json:"created_at"`
+	// the run is being made by a server until then, NULL when it is not claimed
+	ClaimedUntil *time.Time `json:"claimed_until"`
+}
+
+type StandingOrderRun struct {
+	ID              int64 `json:"id"`
+	StandingOrderID int64 `json:"standing_order_id"`
+	// the date of the run in the schedule, the attempt is made later
+	ScheduledFor time.Time `json:"scheduled_for"`
+	Attempt      int32     `json:"attempt"`
+	MaxAttempts  int32     `json:"max_attempts"`
+	// succeeded, pending_approval, retrying, failed or skipped
+	Outcome           string     `json:"outcome"`
+	TransferID        *int64     `json:"transfer_id"`
+	PendingTransferID *int64     `json:"pending_transfer_id"`
+	Error             string     `json:"error"`
+	RetryAt           *time.Time `json:"retry_at"`
+	CreatedAt         time.Time  `json:"created_at"`
 }
 
 type Transfer struct {
@@ -29,6 +181,24 @@ type Transfer struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	// cannot be negative
-	Amount    int64     `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	Amount       money.Amount `json:"amount"`
+	CreatedAt    time.Time    `json:"created_at"`
+	FromCurrency string       `json:"from_currency"`
+	ToCurrency   string       `json:"to_currency"`
+	// credited_amount = amount * exchange_rate, rounded down
+	ExchangeRate string `json:"exchange_rate"`
+	// in to_currency, cannot be negative
+	CreditedAmount money.Amount `json:"credited_amount"`
+	// in from_currency, the sender is debited with amount + fee
+	Fee money.Amount `json:"fee"`
+}
+
+type User struct {
+	Username          string    `json:"username"`
+	HashedPassword    string    `json:"hashed_password"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	Role              string    `json:"role"`
 }
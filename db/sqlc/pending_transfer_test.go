@@ -0,0 +1,272 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/money"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// setApprovalThreshold sets the approval threshold of CHF, and removes it at the end of the test
+// no other test uses CHF, so the threshold doesn't apply to their transfers
+func setApprovalThreshold(t *testing.T, threshold money.Amount) {
+	_, err := testQueries.SetCurrencyTransferLimit(context.Background(), SetCurrencyTransferLimitParams{
+		Currency:          "CHF",
+		ApprovalThreshold: amountLimit(threshold),
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_, err := testQueries.SetCurrencyTransferLimit(context.Background(), SetCurrencyTransferLimitParams{Currency: "CHF"})
+		require.NoError(t, err)
+	})
+}
+
+// requestLargeTransfer requests a transfer above the threshold, and checks that nothing was moved
+func requestLargeTransfer(t *testing.T, store Store, from Account, to Account, amount money.Amount) PendingTransfer {
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        money.New(amount, "CHF"),
+		RequestedBy:   from.Owner,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Transfer.ID)
+	require.NotNil(t, result.PendingTransfer)
+
+	pending := *result.PendingTransfer
+	require.NotZero(t, pending.ID)
+	require.Equal(t, from.ID, pending.FromAccountID)
+	require.Equal(t, to.ID, pending.ToAccountID)
+	require.Equal(t, amount, pending.Amount)
+	require.Equal(t, "CHF", pending.Currency)
+	require.Equal(t, utils.PendingTransferStatus, pending.Status)
+	require.Equal(t, from.Owner, pending.RequestedBy)
+	require.Nil(t, pending.ReviewedBy)
+	require.Nil(t, pending.TransferID)
+
+	updatedFrom, err := testQueries.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance, updatedFrom.Balance)
+	return pending
+}
+
+func TestTransferTxApproval(t *testing.T) {
+	store := NewStore(testDB)
+	setApprovalThreshold(t, 100)
+
+	account1 := createRandomAccountWithCurrency(t, 1000, "CHF")
+	account2 := createRandomAccountWithCurrency(t, 0, "CHF")
+	banker := createRandomUser(t)
+
+	// a transfer of exactly the threshold is executed right away
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(100, "CHF"),
+		RequestedBy:   account1.Owner,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, result.Transfer.ID)
+	require.Nil(t, result.PendingTransfer)
+	account1.Balance -= 100
+
+	pending := requestLargeTransfer(t, store, account1, account2, 500)
+
+	// the user who requested it cannot approve it
+	arg := ReviewPendingTransferTxParams{PendingTransferID: pending.ID, Reviewer: account1.Owner}
+	_, err = store.ApprovePendingTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrSelfReview)
+
+	arg.Reviewer = banker.Username
+	result, err = store.ApprovePendingTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, result.Transfer.ID)
+	require.Equal(t, money.Amount(500), result.Transfer.Amount)
+	require.Equal(t, account1.Balance-500, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+600, result.ToAccount.Balance)
+
+	executed := result.PendingTransfer
+	require.NotNil(t, executed)
+	require.Equal(t, utils.ExecutedTransferStatus, executed.Status)
+	require.Equal(t, &banker.Username, executed.ReviewedBy)
+	require.NotNil(t, executed.ReviewedAt)
+	require.Equal(t, &result.Transfer.ID, executed.TransferID)
+
+	// it cannot be executed twice, or rejected once executed
+	_, err = store.ApprovePendingTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrPendingTransferNotPending)
+	_, err = store.RejectPendingTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrPendingTransferNotPending)
+
+	// the audit trail tells who did what
+	events, err := testQueries.ListPendingTransferEvents(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	require.Equal(t, utils.RequestedTransferAction, events[0].Action)
+	require.Equal(t, &account1.Owner, events[0].Actor)
+	require.Equal(t, utils.PendingTransferStatus, events[0].Status)
+
+	require.Equal(t, utils.ApprovedTransferAction, events[1].Action)
+	require.Equal(t, &banker.Username, events[1].Actor)
+	require.Equal(t, utils.ApprovedTransferStatus, events[1].Status)
+
+	require.Equal(t, utils.ExecutedTransferAction, events[2].Action)
+	require.Equal(t, &banker.Username, events[2].Actor)
+	require.Equal(t, utils.ExecutedTransferStatus, events[2].Status)
+}
+
+func TestRejectPendingTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	setApprovalThreshold(t, 100)
+
+	account1 := createRandomAccountWithCurrency(t, 1000, "CHF")
+	account2 := createRandomAccountWithCurrency(t, 0, "CHF")
+	banker := createRandomUser(t)
+
+	pending := requestLargeTransfer(t, store, account1, account2, 500)
+
+	arg := ReviewPendingTransferTxParams{
+		PendingTransferID: pending.ID,
+		Reviewer:          banker.Username,
+		Note:              "the receiver is not known",
+	}
+	rejected, err := store.RejectPendingTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, utils.RejectedTransferStatus, rejected.Status)
+	require.Equal(t, &banker.Username, rejected.ReviewedBy)
+	require.Nil(t, rejected.TransferID)
+
+	// a rejected transfer can never be approved
+	_, err = store.ApprovePendingTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrPendingTransferNotPending)
+
+	events, err := testQueries.ListPendingTransferEvents(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, utils.RejectedTransferAction, events[1].Action)
+	require.Equal(t, arg.Note, events[1].Note)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestApprovePendingTransferTxExecutionFailed(t *testing.T) {
+	store := NewStore(testDB)
+	setApprovalThreshold(t, 100)
+
+	// the sender doesn't have the money yet
+	account1 := createRandomAccountWithCurrency(t, 0, "CHF")
+	account2 := createRandomAccountWithCurrency(t, 0, "CHF")
+	banker := createRandomUser(t)
+
+	pending := requestLargeTransfer(t, store, account1, account2, 500)
+
+	arg := ReviewPendingTransferTxParams{PendingTransferID: pending.ID, Reviewer: banker.Username}
+	result, err := store.ApprovePendingTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.Equal(t, utils.ApprovedTransferStatus, result.PendingTransfer.Status)
+
+	events, err := testQueries.ListPendingTransferEvents(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.Equal(t, utils.ExecutionFailedTransferAction, events[2].Action)
+	require.Equal(t, utils.ApprovedTransferStatus, events[2].Status)
+	require.Contains(t, events[2].Note, ErrInsufficientFunds.Error())
+
+	// once the money is there, the approval can be retried
+	_, err = store.DepositTx(context.Background(), CashTxParams{AccountID: account1.ID, Amount: money.New(500, "CHF"), CreatedBy: banker.Username})
+	require.NoError(t, err)
+
+	result, err = store.ApprovePendingTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, utils.ExecutedTransferStatus, result.PendingTransfer.Status)
+	require.Zero(t, result.FromAccount.Balance)
+}
+
+func TestApprovePendingTransferTxExpired(t *testing.T) {
+	// the pending transfers of this store are already expired when they are created
+	store := NewStore(testDB, WithApprovalExpiry(-time.Minute))
+	setApprovalThreshold(t, 100)
+
+	account1 := createRandomAccountWithCurrency(t, 1000, "CHF")
+	account2 := createRandomAccountWithCurrency(t, 0, "CHF")
+	banker := createRandomUser(t)
+
+	pending := requestLargeTransfer(t, store, account1, account2, 500)
+
+	arg := ReviewPendingTransferTxParams{PendingTransferID: pending.ID, Reviewer: banker.Username}
+	result, err := store.ApprovePendingTransferTx(context.Background(), arg)
+	require.True(t, errors.Is(err, ErrPendingTransferExpired))
+	require.Equal(t, utils.ExpiredTransferStatus, result.PendingTransfer.Status)
+
+	// the expiry is saved, even if the approval failed
+	expired, err := testQueries.GetPendingTransfer(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Equal(t, utils.ExpiredTransferStatus, expired.Status)
+	require.Nil(t, expired.ReviewedBy)
+
+	events, err := testQueries.ListPendingTransferEvents(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, utils.ExpiredTransferAction, events[1].Action)
+	require.Nil(t, events[1].Actor)
+
+	_, err = store.ApprovePendingTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrPendingTransferNotPending)
+}
+
+func TestExpireDuePendingTransferTx(t *testing.T) {
+	// the pending transfers of this store are already expired when they are created
+	store := NewStore(testDB, WithApprovalExpiry(-time.Minute))
+	setApprovalThreshold(t, 100)
+
+	account1 := createRandomAccountWithCurrency(t, 1000, "CHF")
+	account2 := createRandomAccountWithCurrency(t, 0, "CHF")
+	pending := requestLargeTransfer(t, store, account1, account2, 500)
+
+	// other tests may have left expired pending transfers, they are all expired
+	expired := false
+	for {
+		result, err := store.ExpireDuePendingTransferTx(context.Background(), time.Now())
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, utils.ExpiredTransferStatus, result.Status)
+		if result.ID == pending.ID {
+			expired = true
+		}
+	}
+	require.True(t, expired)
+
+	// nobody reviewed it, the expiry is in its audit trail
+	updated, err := testQueries.GetPendingTransfer(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Equal(t, utils.ExpiredTransferStatus, updated.Status)
+	require.Nil(t, updated.ReviewedBy)
+
+	events, err := testQueries.ListPendingTransferEvents(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, utils.ExpiredTransferAction, events[1].Action)
+	require.Nil(t, events[1].Actor)
+
+	// it is no longer listed as pending, the page starts right before it
+	listed, err := testQueries.ListPendingTransfers(context.Background(), ListPendingTransfersParams{
+		Status:         sql.NullString{String: utils.PendingTransferStatus, Valid: true},
+		AfterCreatedAt: pending.CreatedAt,
+		AfterID:        pending.ID - 1,
+		Limit:          10,
+	})
+	require.NoError(t, err)
+	for _, transfer := range listed {
+		require.NotEqual(t, pending.ID, transfer.ID)
+	}
+}
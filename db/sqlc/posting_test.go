@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/money"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// createRandomPosting posts a random amount to the account
+// A posting always belongs to a balanced journal entry, so the other side goes to the suspense account
+func createRandomPosting(t *testing.T, account Account) Posting {
+	amount := money.New(utils.GenerateAmount(), account.Currency)
+	result := postAdjustment(t, account, amount)
+
+	posting := result.Postings[0]
+	require.NotEmpty(t, posting)
+	require.Equal(t, amount.Amount, posting.Amount)
+	require.Equal(t, amount.Currency, posting.Currency)
+	require.Equal(t, account.ID, posting.AccountID)
+	require.Equal(t, result.JournalEntry.ID, posting.JournalEntryID)
+
+	require.NotZero(t, posting.ID)
+	require.NotZero(t, posting.CreatedAt)
+
+	return posting
+}
+
+// postAdjustment posts an adjustment journal entry that adds the amount to the account, against the suspense account
+func postAdjustment(t *testing.T, account Account, amount money.Money) JournalEntryResult {
+	ctx := context.Background()
+	store := NewStore(testDB).(*SQLStore)
+
+	other, err := amount.Neg()
+	require.NoError(t, err)
+
+	var result JournalEntryResult
+	err = store.execTx(ctx, readCommittedTx, func(q *Queries) error {
+		suspense, err := systemAccount(ctx, q, utils.SuspenseAccountOwner, account.Currency)
+		if err != nil {
+			return err
+		}
+
+		result, err = postJournalEntry(ctx, q, JournalEntryParams{
+			Kind: utils.AdjustmentEntryKind,
+			Lines: []PostingLine{
+				{AccountID: account.ID, Amount: amount},
+				{AccountID: suspense.ID, Amount: other},
+			},
+		})
+		return err
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func TestCreatePosting(t *testing.T) {
+	// We first need to create an account that we are going to use
+	// for creating our posting
+	account := createRandomAccount(t)
+	posting := createRandomPosting(t, account)
+
+	// the balance of the account was updated with the posting
+	updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+posting.Amount, updatedAccount.Balance)
+}
+
+func TestGetPosting(t *testing.T) {
+
+	// We first need to create an account that we are going to use
+	// for creating our posting
+	account := createRandomAccount(t)
+	posting1 := createRandomPosting(t, account)
+
+	posting2, err := testQueries.GetPosting(context.Background(), posting1.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, posting2)
+
+	require.Equal(t, posting1.ID, posting2.ID)
+	require.Equal(t, posting1.Amount, posting2.Amount)
+	require.Equal(t, posting1.AccountID, posting2.AccountID)
+	require.Equal(t, posting1.JournalEntryID, posting2.JournalEntryID)
+	require.Equal(t, posting1.Currency, posting2.Currency)
+	require.WithinDuration(t, posting1.CreatedAt, posting2.CreatedAt, time.Second)
+}
+
+func TestListPostings(t *testing.T) {
+
+	// We first need to create an account that we are going to use
+	// for creating our postings
+	account := createRandomAccount(t)
+	// For the same account, we will create multiple postings for it
+	for i := 0; i < 10; i++ {
+		createRandomPosting(t, account)
+	}
+
+	arg := ListPostingsParams{
+		Limit:     5,
+		Offset:    5,
+		AccountID: account.ID,
+	}
+	postings, err := testQueries.ListPostings(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, postings, 5)
+
+	for _, posting := range postings {
+		require.NotEmpty(t, posting)
+	}
+}
+
+func TestListPostingsAfter(t *testing.T) {
+	account := createRandomAccount(t)
+	for i := 0; i < 10; i++ {
+		createRandomPosting(t, account)
+	}
+
+	// we read all the postings page by page, following the last posting of each page
+	var postings []Posting
+	var last Posting
+	for {
+		page, err := testQueries.ListPostingsAfter(context.Background(), ListPostingsAfterParams{
+			AccountID:      account.ID,
+			AfterCreatedAt: last.CreatedAt,
+			AfterID:        last.ID,
+			Limit:          3,
+		})
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		postings = append(postings, page...)
+		last = page[len(page)-1]
+	}
+
+	// every posting is returned exactly once
+	require.Len(t, postings, 10)
+	seen := make(map[int64]bool)
+	for _, posting := range postings {
+		require.Equal(t, account.ID, posting.AccountID)
+		require.False(t, seen[posting.ID])
+		seen[posting.ID] = true
+	}
+}
+
+func TestListJournalEntryPostings(t *testing.T) {
+	account := createRandomAccount(t)
+	posting := createRandomPosting(t, account)
+
+	postings, err := testQueries.ListJournalEntryPostings(context.Background(), posting.JournalEntryID)
+	require.NoError(t, err)
+	require.Len(t, postings, 2)
+	require.Equal(t, posting, postings[0])
+
+	// the other side is the suspense account, in the same currency
+	suspense, err := testQueries.GetAccount(context.Background(), postings[1].AccountID)
+	require.NoError(t, err)
+	require.Equal(t, utils.SuspenseAccountOwner, suspense.Owner)
+	require.Equal(t, account.Currency, postings[1].Currency)
+	require.Equal(t, -posting.Amount, postings[1].Amount)
+}
@@ -6,22 +6,86 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddAccountHeldAmount(ctx context.Context, arg AddAccountHeldAmountParams) (Account, error)
+	BlockSession(ctx context.Context, id uuid.UUID) (Session, error)
+	BlockUserSessions(ctx context.Context, username string) (int64, error)
+	ClaimDueStandingOrder(ctx context.Context, now time.Time) (StandingOrder, error)
+	ClaimExpiredHold(ctx context.Context, now time.Time) (Hold, error)
+	ClaimExpiredPendingTransfer(ctx context.Context, now time.Time) (PendingTransfer, error)
+	ClaimStandingOrder(ctx context.Context, arg ClaimStandingOrderParams) (StandingOrder, error)
+	CountAccounts(ctx context.Context) (int64, error)
+	CountJournalEntries(ctx context.Context) (int64, error)
+	CountTransfers(ctx context.Context) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
-	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	CreateHoldCapture(ctx context.Context, arg CreateHoldCaptureParams) (HoldCapture, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateJournalEntry(ctx context.Context, arg CreateJournalEntryParams) (JournalEntry, error)
+	CreatePendingTransfer(ctx context.Context, arg CreatePendingTransferParams) (PendingTransfer, error)
+	CreatePendingTransferEvent(ctx context.Context, arg CreatePendingTransferEventParams) (PendingTransferEvent, error)
+	CreatePosting(ctx context.Context, arg CreatePostingParams) (Posting, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateStandingOrder(ctx context.Context, arg CreateStandingOrderParams) (StandingOrder, error)
+	CreateStandingOrderRun(ctx context.Context, arg CreateStandingOrderRunParams) (StandingOrderRun, error)
+	CreateSystemAccount(ctx context.Context, arg CreateSystemAccountParams) error
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
-	DeleteAccount(ctx context.Context, id int64) error
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	ExecutePendingTransfer(ctx context.Context, arg ExecutePendingTransferParams) (PendingTransfer, error)
+	ExpirePendingTransfer(ctx context.Context, id int64) (PendingTransfer, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
-	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetAccountTransferLimit(ctx context.Context, accountID int64) (AccountTransferLimit, error)
+	GetCurrencyTransferLimit(ctx context.Context, currency string) (CurrencyTransferLimit, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Hold, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetJournalEntry(ctx context.Context, id int64) (JournalEntry, error)
+	GetPendingTransfer(ctx context.Context, id int64) (PendingTransfer, error)
+	GetPendingTransferForUpdate(ctx context.Context, id int64) (PendingTransfer, error)
+	GetPosting(ctx context.Context, id int64) (Posting, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
+	GetStandingOrderForUpdate(ctx context.Context, id int64) (StandingOrder, error)
+	GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (Account, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferJournalEntry(ctx context.Context, transferID int64) (JournalEntry, error)
+	GetTransferVelocity(ctx context.Context, arg GetTransferVelocityParams) (GetTransferVelocityRow, error)
+	GetUser(ctx context.Context, username string) (User, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
-	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error)
+	ListBalanceDiscrepancies(ctx context.Context) ([]ListBalanceDiscrepanciesRow, error)
+	ListHeldAmountDiscrepancies(ctx context.Context) ([]ListHeldAmountDiscrepanciesRow, error)
+	ListHoldCaptures(ctx context.Context, holdID int64) ([]HoldCapture, error)
+	ListHolds(ctx context.Context, arg ListHoldsParams) ([]Hold, error)
+	ListJournalEntryPostings(ctx context.Context, journalEntryID int64) ([]Posting, error)
+	ListPendingTransferEvents(ctx context.Context, pendingTransferID int64) ([]PendingTransferEvent, error)
+	ListPendingTransfers(ctx context.Context, arg ListPendingTransfersParams) ([]PendingTransfer, error)
+	ListPostings(ctx context.Context, arg ListPostingsParams) ([]Posting, error)
+	ListPostingsAfter(ctx context.Context, arg ListPostingsAfterParams) ([]Posting, error)
+	ListStandingOrderRuns(ctx context.Context, arg ListStandingOrderRunsParams) ([]StandingOrderRun, error)
+	ListStandingOrders(ctx context.Context, fromAccountID int64) ([]StandingOrder, error)
+	ListTransferDiscrepancies(ctx context.Context) ([]ListTransferDiscrepanciesRow, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListTransfersFiltered(ctx context.Context, arg ListTransfersFilteredParams) ([]Transfer, error)
+	ListUnbalancedJournalEntries(ctx context.Context) ([]ListUnbalancedJournalEntriesRow, error)
+	ReviewPendingTransfer(ctx context.Context, arg ReviewPendingTransferParams) (PendingTransfer, error)
+	ScheduleStandingOrder(ctx context.Context, arg ScheduleStandingOrderParams) (StandingOrder, error)
+	SkipStandingOrderRuns(ctx context.Context, arg SkipStandingOrderRunsParams) (StandingOrder, error)
+	SetAccountTransferLimit(ctx context.Context, arg SetAccountTransferLimitParams) (AccountTransferLimit, error)
+	SetCurrencyTransferLimit(ctx context.Context, arg SetCurrencyTransferLimitParams) (CurrencyTransferLimit, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateHold(ctx context.Context, arg UpdateHoldParams) (Hold, error)
+	UpdateIdempotencyKeyResponse(ctx context.Context, arg UpdateIdempotencyKeyResponseParams) (IdempotencyKey, error)
+	UpdateStandingOrder(ctx context.Context, arg UpdateStandingOrderParams) (StandingOrder, error)
 }
 
 var _ Querier = (*Queries)(nil)
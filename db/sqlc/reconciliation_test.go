@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/money"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// createFundedAccount creates an account and funds it with a posting from the suspense account
+// the accounts of the other tests are created with a balance but without any posting, so they don't reconcile
+func createFundedAccount(t *testing.T, balance money.Amount) Account {
+	account := createRandomAccountWithBalance(t, 0)
+	result := postAdjustment(t, account, money.New(balance, account.Currency))
+	return result.Accounts[account.ID]
+}
+
+func TestReconcileLedger(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(10, "USD"),
+	})
+	require.NoError(t, err)
+
+	// the transfer updated the balances and posted its journal entry, so the ledger is consistent for these accounts
+	report, err := store.ReconcileLedger(context.Background())
+	require.NoError(t, err)
+	require.NotZero(t, report.CheckedAt)
+	require.GreaterOrEqual(t, report.AccountsChecked, int64(2))
+	require.GreaterOrEqual(t, report.TransfersChecked, int64(1))
+	require.GreaterOrEqual(t, report.JournalEntriesChecked, int64(3))
+	requireNoBalanceDiscrepancy(t, report, account1.ID)
+	requireNoBalanceDiscrepancy(t, report, account2.ID)
+	requireNoTransferDiscrepancy(t, report, result.Transfer.ID)
+	require.Empty(t, report.JournalEntryDiscrepancies)
+
+	// now a balanced posting is added to the transfer without changing the balances, like a bug would do
+	ctx := context.Background()
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	q := New(tx)
+	suspense, err := systemAccount(ctx, q, utils.SuspenseAccountOwner, "USD")
+	require.NoError(t, err)
+	_, err = q.CreatePosting(ctx, CreatePostingParams{
+		JournalEntryID: result.JournalEntry.ID,
+		AccountID:      account1.ID,
+		Currency:       "USD",
+		Amount:         -10,
+	})
+	require.NoError(t, err)
+	_, err = q.CreatePosting(ctx, CreatePostingParams{
+		JournalEntryID: result.JournalEntry.ID,
+		AccountID:      suspense.ID,
+		Currency:       "USD",
+		Amount:         10,
+	})
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	report, err = store.ReconcileLedger(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.HasDiscrepancies())
+
+	var balanceDiscrepancy *ListBalanceDiscrepanciesRow
+	for i := range report.BalanceDiscrepancies {
+		if report.BalanceDiscrepancies[i].AccountID == account1.ID {
+			balanceDiscrepancy = &report.BalanceDiscrepancies[i]
+		}
+	}
+	require.NotNil(t, balanceDiscrepancy)
+	require.Equal(t, money.Amount(990), balanceDiscrepancy.Balance)
+	require.Equal(t, int64(980), balanceDiscrepancy.PostingsTotal)
+	requireNoBalanceDiscrepancy(t, report, account2.ID)
+
+	var transferDiscrepancy *ListTransferDiscrepanciesRow
+	for i := range report.TransferDiscrepancies {
+		if report.TransferDiscrepancies[i].TransferID == result.Transfer.ID {
+			transferDiscrepancy = &report.TransferDiscrepancies[i]
+		}
+	}
+	require.NotNil(t, transferDiscrepancy)
+	require.Equal(t, int64(1), transferDiscrepancy.JournalEntryCount)
+	require.Equal(t, int64(2), transferDiscrepancy.DebitCount)
+	require.Equal(t, int64(1), transferDiscrepancy.CreditCount)
+}
+
+func TestReconcileLedgerMissingJournalEntry(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	// a transfer created without its journal entry is reported
+	transfer := createRandomTransfer(t, account1, account2)
+
+	report, err := NewStore(testDB).ReconcileLedger(context.Background())
+	require.NoError(t, err)
+
+	var found bool
+	for _, discrepancy := range report.TransferDiscrepancies {
+		if discrepancy.TransferID == transfer.ID {
+			found = true
+			require.Zero(t, discrepancy.JournalEntryCount)
+			require.Zero(t, discrepancy.DebitCount)
+			require.Zero(t, discrepancy.CreditCount)
+		}
+	}
+	require.True(t, found)
+}
+
+func requireNoBalanceDiscrepancy(t *testing.T, report ReconciliationReport, accountID int64) {
+	for _, discrepancy := range report.BalanceDiscrepancies {
+		require.NotEqual(t, accountID, discrepancy.AccountID)
+	}
+}
+
+func requireNoTransferDiscrepancy(t *testing.T, report ReconciliationReport, transferID int64) {
+	for _, discrepancy := range report.TransferDiscrepancies {
+		require.NotEqual(t, transferID, discrepancy.TransferID)
+	}
+}
@@ -0,0 +1,418 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/money"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// createRandomStandingOrder creates a daily standing order of 10 USD that is due at nextRunAt
+// It is cancelled at the end of the test, so it is never executed by another test
+func createRandomStandingOrder(t *testing.T, from Account, to Account, nextRunAt time.Time) StandingOrder {
+	arg := CreateStandingOrderParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+		Currency:      from.Currency,
+		Frequency:     "daily",
+		IntervalCount: 1,
+		StartAt:       nextRunAt,
+		NextRunAt:     nextRunAt,
+		CreatedBy:     from.Owner,
+	}
+
+	order, err := testQueries.CreateStandingOrder(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, order.ID)
+	require.Equal(t, arg.Amount, order.Amount)
+	require.Equal(t, arg.Frequency, order.Frequency)
+	require.WithinDuration(t, arg.NextRunAt, order.NextRunAt, time.Second)
+	require.Zero(t, order.RunCount)
+	require.Zero(t, order.FailedAttempts)
+	require.Nil(t, order.RetryAt)
+	require.Nil(t, order.EndAt)
+	require.Equal(t, utils.ActiveStandingOrderStatus, order.Status)
+
+	t.Cleanup(func() {
+		_, err := testQueries.UpdateStandingOrder(context.Background(), UpdateStandingOrderParams{
+			ID:     order.ID,
+			Amount: order.Amount,
+			Status: utils.CancelledStandingOrderStatus,
+		})
+		require.NoError(t, err)
+	})
+	return order
+}
+
+func TestListStandingOrders(t *testing.T) {
+	account1 := createRandomAccountWithCurrency(t, 0, "USD")
+	account2 := createRandomAccountWithCurrency(t, 0, "USD")
+
+	start := time.Now().Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		createRandomStandingOrder(t, account1, account2, start)
+	}
+
+	orders, err := testQueries.ListStandingOrders(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Len(t, orders, 3)
+	for _, order := range orders {
+		require.Equal(t, account1.ID, order.FromAccountID)
+	}
+
+	// the receiver doesn't see them
+	orders, err = testQueries.ListStandingOrders(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Empty(t, orders)
+}
+
+func TestExecuteDueStandingOrderTx(t *testing.T) {
+	store := NewStore(testDB)
+	policy := StandingOrderRetryPolicy{MaxAttempts: 3, RetryDelay: time.Hour}
+
+	account1 := createRandomAccountWithCurrency(t, 100, "USD")
+	account2 := createRandomAccountWithCurrency(t, 0, "USD")
+	now := time.Now()
+	order := createRandomStandingOrder(t, account1, account2, now.Add(-time.Minute))
+
+	result, err := store.ExecuteDueStandingOrderTx(context.Background(), ExecuteStandingOrderTxParams{Now: now, RetryPolicy: policy})
+	require.NoError(t, err)
+	require.Equal(t, order.ID, result.StandingOrder.ID)
+
+	run := result.Run
+	require.NotNil(t, run)
+	require.Equal(t, utils.SucceededRunOutcome, run.Outcome)
+	require.Equal(t, int32(1), run.Attempt)
+	require.Equal(t, int32(3), run.MaxAttempts)
+	require.NotNil(t, run.TransferID)
+	require.Empty(t, run.Error)
+
+	transfer, err := testQueries.GetTransfer(context.Background(), *run.TransferID)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, transfer.FromAccountID)
+	require.Equal(t, account2.ID, transfer.ToAccountID)
+	require.Equal(t, money.Amount(10), transfer.Amount)
+
+	// the standing order moves to the next day
+	require.Equal(t, int64(1), result.StandingOrder.RunCount)
+	require.WithinDuration(t, order.NextRunAt.AddDate(0, 0, 1), result.StandingOrder.NextRunAt, time.Second)
+	require.Equal(t, utils.ActiveStandingOrderStatus, result.StandingOrder.Status)
+
+	// the next run is not due yet
+	_, err = store.ExecuteDueStandingOrderTx(context.Background(), ExecuteStandingOrderTxParams{Now: now, RetryPolicy: policy})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(90), updatedAccount1.Balance)
+}
+
+func TestExecuteDueStandingOrderTxRetry(t *testing.T) {
+	store := NewStore(testDB)
+	policy := StandingOrderRetryPolicy{MaxAttempts: 2, RetryDelay: time.Hour}
+
+	// the sender has no money, so every attempt fails
+	account1 := createRandomAccountWithCurrency(t, 0, "USD")
+	account2 := createRandomAccountWithCurrency(t, 0, "USD")
+	now := time.Now()
+	order := createRandomStandingOrder(t, account1, account2, now.Add(-time.Minute))
+
+	result, err := store.ExecuteDueStandingOrderTx(context.Background(), ExecuteStandingOrderTxParams{Now: now, RetryPolicy: policy})
+	require.NoError(t, err)
+	require.Equal(t, order.ID, result.StandingOrder.ID)
+	require.Equal(t, utils.RetryingRunOutcome, result.Run.Outcome)
+	require.Equal(t, int32(1), result.Run.Attempt)
+	require.Contains(t, result.Run.Error, ErrInsufficientFunds.Error())
+	require.Nil(t, result.Run.TransferID)
+	require.NotNil(t, result.Run.RetryAt)
+	require.WithinDuration(t, now.Add(time.Hour), *result.Run.RetryAt, time.Second)
+
+	// the same run waits for its retry
+	require.Zero(t, result.StandingOrder.RunCount)
+	require.Equal(t, int32(1), result.StandingOrder.FailedAttempts)
+	require.WithinDuration(t, order.NextRunAt, result.StandingOrder.NextRunAt, time.Second)
+
+	_, err = store.ExecuteDueStandingOrderTx(context.Background(), ExecuteStandingOrderTxParams{Now: now, RetryPolicy: policy})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the last attempt fails too, so the run is skipped and the standing order moves to the next day
+	later := now.Add(time.Hour)
+	result, err = store.ExecuteDueStandingOrderTx(context.Background(), ExecuteStandingOrderTxParams{Now: later, RetryPolicy: policy})
+	require.NoError(t, err)
+	require.Equal(t, order.ID, result.StandingOrder.ID)
+	require.Equal(t, utils.FailedRunOutcome, result.Run.Outcome)
+	require.Equal(t, int32(2), result.Run.Attempt)
+	require.Nil(t, result.Run.RetryAt)
+
+	require.Equal(t, int64(1), result.StandingOrder.RunCount)
+	require.Zero(t, result.StandingOrder.FailedAttempts)
+	require.Nil(t, result.StandingOrder.RetryAt)
+	require.WithinDuration(t, order.NextRunAt.AddDate(0, 0, 1), result.StandingOrder.NextRunAt, time.Second)
+
+	runs, err := testQueries.ListStandingOrderRuns(context.Background(), ListStandingOrderRunsParams{
+		StandingOrderID: order.ID,
+		Limit:           10,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, utils.FailedRunOutcome, runs[0].Outcome) // most recent first
+	require.Equal(t, utils.RetryingRunOutcome, runs[1].Outcome)
+}
+
+func TestExecuteDueStandingOrderTxCompleted(t *testing.T) {
+	store := NewStore(testDB)
+	policy := StandingOrderRetryPolicy{MaxAttempts: 1}
+
+	account1 := createRandomAccountWithCurrency(t, 100, "USD")
+	account2 := createRandomAccountWithCurrency(t, 0, "USD")
+	now := time.Now()
+	order := createRandomStandingOrder(t, account1, account2, now.Add(-time.Minute))
+
+	// the standing order ends before its second run
+	end := order.StartAt.Add(time.Hour)
+	_, err := testQueries.UpdateStandingOrder(context.Background(), UpdateStandingOrderParams{
+		ID:     order.ID,
+		Amount: order.Amount,
+		EndAt:  &end,
+		Status: order.Status,
+	})
+	require.NoError(t, err)
+
+	result, err := store.ExecuteDueStandingOrderTx(context.Background(), ExecuteStandingOrderTxParams{Now: now, RetryPolicy: policy})
+	require.NoError(t, err)
+	require.Equal(t, utils.SucceededRunOutcome, result.Run.Outcome)
+	require.Equal(t, utils.CompletedStandingOrderStatus, result.StandingOrder.Status)
+	require.Equal(t, int64(1), result.StandingOrder.RunCount)
+}
+
+func TestExecuteDueStandingOrderTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+	policy := StandingOrderRetryPolicy{MaxAttempts: 3, RetryDelay: time.Hour}
+
+	account1 := createRandomAccountWithCurrency(t, 100, "USD")
+	account2 := createRandomAccountWithCurrency(t, 0, "USD")
+	now := time.Now()
+	order := createRandomStandingOrder(t, account1, account2, now.Add(-time.Minute))
+
+	// several servers run their scheduler at the same time
+	n := 5
+	errs := make(chan error)
+	results := make(chan StandingOrderTxResult)
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := store.ExecuteDueStandingOrderTx(context.Background(), ExecuteStandingOrderTxParams{Now: now, RetryPolicy: policy})
+			errs <- err
+			results <- result
+		}()
+	}
+
+	executed := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		result := <-results
+		if err == sql.ErrNoRows {
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, order.ID, result.StandingOrder.ID)
+		executed++
+	}
+	require.Equal(t, 1, executed)
+
+	// the run was only made once
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(90), updatedAccount1.Balance)
+}
+
+func TestExecuteDueStandingOrderTxAfterCrash(t *testing.T) {
+	store := NewStore(testDB).(*SQLStore)
+	policy := StandingOrderRetryPolicy{MaxAttempts: 3, RetryDelay: time.Hour}
+
+	account1 := createRandomAccountWithCurrency(t, 100, "USD")
+	account2 := createRandomAccountWithCurrency(t, 0, "USD")
+	now := time.Now()
+	order := createRandomStandingOrder(t, account1, account2, now.Add(-time.Minute))
+
+	// a server made the transfer of the run, and crashed before saving the run
+	crashed, err := store.standingOrderTransfer(context.Background(), order)
+	require.NoError(t, err)
+	require.NotZero(t, crashed.Transfer.ID)
+
+	// the next attempt saves the same transfer instead of making it again
+	result, err := store.ExecuteDueStandingOrderTx(context.Background(), ExecuteStandingOrderTxParams{Now: now, RetryPolicy: policy})
+	require.NoError(t, err)
+	require.Equal(t, order.ID, result.StandingOrder.ID)
+	require.Equal(t, utils.SucceededRunOutcome, result.Run.Outcome)
+	require.Equal(t, &crashed.Transfer.ID, result.Run.TransferID)
+	require.Equal(t, int64(1), result.StandingOrder.RunCount)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(90), updatedAccount1.Balance)
+}
+
+func TestExecuteDueStandingOrderTxSingleConnection(t *testing.T) {
+	config, err := utils.LoadConfig("../..")
+	require.NoError(t, err)
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the claim is committed before the transfer is made, so a pool of one connection is enough
+	conn.SetMaxOpenConns(1)
+	store := NewStore(conn)
+	policy := StandingOrderRetryPolicy{MaxAttempts: 3, RetryDelay: time.Hour}
+
+	account1 := createRandomAccountWithCurrency(t, 100, "USD")
+	account2 := createRandomAccountWithCurrency(t, 0, "USD")
+	now := time.Now()
+	order := createRandomStandingOrder(t, account1, account2, now.Add(-time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := store.ExecuteDueStandingOrderTx(ctx, ExecuteStandingOrderTxParams{Now: now, RetryPolicy: policy})
+	require.NoError(t, err)
+	require.Equal(t, order.ID, result.StandingOrder.ID)
+	require.Equal(t, utils.SucceededRunOutcome, result.Run.Outcome)
+	require.Nil(t, result.StandingOrder.ClaimedUntil)
+}
+
+func TestExecuteDueStandingOrderTxClaimed(t *testing.T) {
+	store := NewStore(testDB)
+	policy := StandingOrderRetryPolicy{MaxAttempts: 3, RetryDelay: time.Hour}
+
+	account1 := createRandomAccountWithCurrency(t, 100, "USD")
+	account2 := createRandomAccountWithCurrency(t, 0, "USD")
+	now := time.Now()
+	order := createRandomStandingOrder(t, account1, account2, now.Add(-time.Minute))
+
+	// another server claimed the standing order and is making its transfer
+	claimedUntil := now.Add(standingOrderClaimLease)
+	_, err := testQueries.ClaimStandingOrder(context.Background(), ClaimStandingOrderParams{
+		ID:           order.ID,
+		ClaimedUntil: &claimedUntil,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ExecuteDueStandingOrderTx(context.Background(), ExecuteStandingOrderTxParams{Now: now, RetryPolicy: policy})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// that server stopped, once its claim is over the standing order runs again
+	later := claimedUntil.Add(time.Second)
+	result, err := store.ExecuteDueStandingOrderTx(context.Background(), ExecuteStandingOrderTxParams{Now: later, RetryPolicy: policy})
+	require.NoError(t, err)
+	require.Equal(t, order.ID, result.StandingOrder.ID)
+	require.Equal(t, utils.SucceededRunOutcome, result.Run.Outcome)
+}
+
+func TestUpdateStandingOrderTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithCurrency(t, 100, "USD")
+	account2 := createRandomAccountWithCurrency(t, 0, "USD")
+	order := createRandomStandingOrder(t, account1, account2, time.Now().Add(time.Hour))
+
+	// the fields that are not set don't change
+	amount := money.Amount(20)
+	updated, err := store.UpdateStandingOrderTx(context.Background(), UpdateStandingOrderTxParams{
+		ID:     order.ID,
+		Amount: &amount,
+	})
+	require.NoError(t, err)
+	require.Equal(t, amount, updated.Amount)
+	require.Equal(t, order.Status, updated.Status)
+	require.Nil(t, updated.EndAt)
+
+	// the scheduler completes the standing order after its owner has read it
+	_, err = testQueries.ScheduleStandingOrder(context.Background(), ScheduleStandingOrderParams{
+		ID:        order.ID,
+		NextRunAt: order.NextRunAt,
+		RunCount:  order.RunCount,
+		Status:    utils.CompletedStandingOrderStatus,
+	})
+	require.NoError(t, err)
+
+	// the stale change of the owner doesn't reopen it
+	_, err = store.UpdateStandingOrderTx(context.Background(), UpdateStandingOrderTxParams{
+		ID:     order.ID,
+		Status: utils.ActiveStandingOrderStatus,
+	})
+	require.ErrorIs(t, err, ErrStandingOrderClosed)
+
+	_, err = store.UpdateStandingOrderTx(context.Background(), UpdateStandingOrderTxParams{
+		ID:     order.ID,
+		Status: utils.CancelledStandingOrderStatus,
+	})
+	require.ErrorIs(t, err, ErrStandingOrderClosed)
+
+	completed, err := testQueries.GetStandingOrder(context.Background(), order.ID)
+	require.NoError(t, err)
+	require.Equal(t, utils.CompletedStandingOrderStatus, completed.Status)
+}
+
+func TestUpdateStandingOrderTxResume(t *testing.T) {
+	store := NewStore(testDB)
+	policy := StandingOrderRetryPolicy{MaxAttempts: 3, RetryDelay: time.Hour}
+
+	account1 := createRandomAccountWithCurrency(t, 100, "USD")
+	account2 := createRandomAccountWithCurrency(t, 0, "USD")
+
+	// a daily standing order was paused before its first run, three days ago
+	now := time.Now()
+	order := createRandomStandingOrder(t, account1, account2, now.AddDate(0, 0, -3).Add(time.Hour))
+	_, err := store.UpdateStandingOrderTx(context.Background(), UpdateStandingOrderTxParams{
+		ID:     order.ID,
+		Status: utils.PausedStandingOrderStatus,
+	})
+	require.NoError(t, err)
+
+	// it is resumed, the three runs it missed are skipped and it moves to the run of today
+	resumed, err := store.UpdateStandingOrderTx(context.Background(), UpdateStandingOrderTxParams{
+		ID:     order.ID,
+		Status: utils.ActiveStandingOrderStatus,
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.ActiveStandingOrderStatus, resumed.Status)
+	require.Equal(t, int64(3), resumed.RunCount)
+	require.WithinDuration(t, order.StartAt.AddDate(0, 0, 3), resumed.NextRunAt, time.Second)
+	require.True(t, resumed.NextRunAt.After(now))
+	require.Zero(t, resumed.FailedAttempts)
+	require.Nil(t, resumed.RetryAt)
+
+	runs, err := testQueries.ListStandingOrderRuns(context.Background(), ListStandingOrderRunsParams{
+		StandingOrderID: order.ID,
+		Limit:           10,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+	for i, run := range runs {
+		require.Equal(t, utils.SkippedRunOutcome, run.Outcome)
+		require.Zero(t, run.Attempt)
+		require.Nil(t, run.TransferID)
+		// most recent first
+		require.WithinDuration(t, order.StartAt.AddDate(0, 0, 2-i), run.ScheduledFor, time.Second)
+	}
+
+	// none of the missed runs is made by the scheduler
+	_, err = store.ExecuteDueStandingOrderTx(context.Background(), ExecuteStandingOrderTxParams{Now: now, RetryPolicy: policy})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(100), updatedAccount1.Balance)
+}
+
+func TestStandingOrderRetryDelay(t *testing.T) {
+	policy := StandingOrderRetryPolicy{MaxAttempts: 10, RetryDelay: time.Hour}
+
+	require.Equal(t, time.Hour, policy.retryDelay(1))
+	require.Equal(t, 2*time.Hour, policy.retryDelay(2))
+	require.Equal(t, 8*time.Hour, policy.retryDelay(4))
+	// the delay never grows beyond a day
+	require.Equal(t, maxStandingOrderRetryDelay, policy.retryDelay(10))
+}
@@ -3,8 +3,21 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/fee"
+	"github.com/elmas23/simplebank/fx"
+	"github.com/elmas23/simplebank/money"
+	"github.com/elmas23/simplebank/schedule"
 	_ "github.com/golang/mock/mockgen/model" // to allow mockgen to work properly
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 /*
@@ -101,6 +114,22 @@ uncommitted transactions, thus allowing dirty read phenomenon to happen.
 type Store interface {
 	Querier // this is the interface generated by sqlc
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error)
+	SetOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error)
+	SetAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	ReconcileLedger(ctx context.Context) (ReconciliationReport, error)
+	DepositTx(ctx context.Context, arg CashTxParams) (CashTxResult, error)
+	WithdrawTx(ctx context.Context, arg CashTxParams) (CashTxResult, error)
+	ApprovePendingTransferTx(ctx context.Context, arg ReviewPendingTransferTxParams) (TransferTxResult, error)
+	RejectPendingTransferTx(ctx context.Context, arg ReviewPendingTransferTxParams) (PendingTransfer, error)
+	ExpireDuePendingTransferTx(ctx context.Context, now time.Time) (PendingTransfer, error)
+	ExecuteDueStandingOrderTx(ctx context.Context, arg ExecuteStandingOrderTxParams) (StandingOrderTxResult, error)
+	UpdateStandingOrderTx(ctx context.Context, arg UpdateStandingOrderTxParams) (StandingOrder, error)
+	CreateHoldTx(ctx context.Context, arg CreateHoldTxParams) (Hold, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (TransferTxResult, error)
+	ReleaseHoldTx(ctx context.Context, holdID int64) (Hold, error)
+	ExpireDueHoldTx(ctx context.Context, now time.Time) (Hold, error)
+	TxStats() TxStats
 }
 
 // SQLStore provides all functions to execute db queries and transactions
@@ -109,21 +138,200 @@ type SQLStore struct {
 	*Queries // Queries struct does not support transaction, so we extend the struct here to add
 	// transaction support
 	db *sql.DB // needs to create new db transaction
+	// the options used by the transactions that don't declare their own
+	defaultTxOptions *sql.TxOptions
+	// the exchange rates of the transfers between two currencies, it is nil if they are not supported
+	rates fx.RateProvider
+	// the fees of the transfers, they are free if it is nil
+	fees fee.Schedule
+	// how long a transfer that needs an approval can wait for it
+	approvalExpiry time.Duration
+	// how long a hold reserves its money before it expires
+	holdExpiry time.Duration
+	// these counters are updated by execTx every time a transaction is retried
+	// they are atomic since the store is shared by all the requests of the server
+	retries               atomic.Uint64
+	serializationFailures atomic.Uint64
+	deadlocks             atomic.Uint64
+	exhaustedRetries      atomic.Uint64
 }
 
 // NewStore creates a new SQLStore
 // New store now no longer return a pointer but just a Store interface
-func NewStore(db *sql.DB) Store {
-	return &SQLStore{
-		db:      db,
-		Queries: New(db),
+// The options can be used to change the default behaviour of the store, for example WithDefaultIsolation
+func NewStore(db *sql.DB, opts ...StoreOption) Store {
+	store := &SQLStore{
+		db:             db,
+		Queries:        New(db),
+		approvalExpiry: defaultApprovalExpiry,
+		holdExpiry:     defaultHoldExpiry,
+	}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store
+}
+
+// StoreOption changes a setting of the SQLStore when it is created
+type StoreOption func(store *SQLStore)
+
+// WithDefaultIsolation sets the isolation level of the transactions that don't declare their own
+// Without this option, the default isolation level of the database is used, which is read committed for postgres
+func WithDefaultIsolation(level sql.IsolationLevel) StoreOption {
+	return func(store *SQLStore) {
+		store.defaultTxOptions = &sql.TxOptions{Isolation: level}
+	}
+}
+
+// WithRateProvider sets where the exchange rates of the transfers between two currencies come from
+// Without this option, a transfer can only be made between two accounts of the same currency
+func WithRateProvider(rates fx.RateProvider) StoreOption {
+	return func(store *SQLStore) {
+		store.rates = rates
+	}
+}
+
+// WithFeeSchedule sets the fees paid by the senders of the transfers
+// Without this option, the transfers are free
+func WithFeeSchedule(fees fee.Schedule) StoreOption {
+	return func(store *SQLStore) {
+		store.fees = fees
+	}
+}
+
+// WithApprovalExpiry sets how long a transfer that needs an approval can wait for it, see the pending transfers
+// Without this option, it expires after defaultApprovalExpiry
+func WithApprovalExpiry(expiry time.Duration) StoreOption {
+	return func(store *SQLStore) {
+		store.approvalExpiry = expiry
+	}
+}
+
+// WithHoldExpiry sets how long a hold reserves its money, it is released if it is not captured in time
+// Without this option, it expires after defaultHoldExpiry
+func WithHoldExpiry(expiry time.Duration) StoreOption {
+	return func(store *SQLStore) {
+		store.holdExpiry = expiry
+	}
+}
+
+// ParseIsolationLevel converts the isolation level of the config into a sql.IsolationLevel
+// It accepts the names used by postgres, with spaces or underscores, like "repeatable read" or "repeatable_read"
+// An empty string means the default isolation level of the database
+func ParseIsolationLevel(level string) (sql.IsolationLevel, error) {
+	switch strings.ReplaceAll(strings.ToLower(strings.TrimSpace(level)), "_", " ") {
+	case "", "default":
+		return sql.LevelDefault, nil
+	case "read uncommitted":
+		return sql.LevelReadUncommitted, nil
+	case "read committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	}
+	return sql.LevelDefault, fmt.Errorf("unsupported isolation level: %q", level)
+}
+
+/*
+Which isolation level for which operation ?
+
+		Each multi-step operation of the store declares the isolation it needs when it calls execTx.
+		When it passes nil, the default of the store is used.
+
+				- readCommittedTx: enough for the operations that only write through row locks, like TransferTx.
+				  The UPDATE of AddAccountBalance locks the row and always sees the latest committed balance,
+				  so concurrent transfers cannot lose an update even at the lowest level that postgres supports.
+				- readOnlySnapshotTx: for reports like account statements and the reconciliation of the ledger.
+				  All the queries see the same snapshot of the database, so the lines of a statement always add up,
+				  and nothing can be written by mistake. A single snapshot is all that these read only operations need.
+*/
+
+var (
+	readCommittedTx    = &sql.TxOptions{Isolation: sql.LevelReadCommitted}
+	readOnlySnapshotTx = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+)
+
+/*
+Why do we retry transactions ?
+
+		Postgres can abort a transaction that conflicts with another concurrent transaction:
+
+				- 40001 serialization_failure: the transaction could not be serialized with the others,
+				  this happens with the repeatable read and serializable isolation levels
+				- 40P01 deadlock_detected: two transactions were waiting for a lock held by each other,
+				  postgres kills one of them so that the other can continue
+
+		In both cases nothing was saved, and running the exact same transaction again will most likely succeed.
+		So instead of returning a 500 to the client, execTx runs the whole callback again.
+		We wait a bit between attempts, and the wait grows exponentially, so that the conflicting transactions
+		have time to finish. A random jitter is used so that the retried transactions don't collide again.
+*/
+
+const (
+	maxTxRetries     = 5                      // the callback runs at most maxTxRetries+1 times
+	txRetryBaseDelay = 10 * time.Millisecond  // the maximum wait before the first retry
+	txRetryMaxDelay  = 500 * time.Millisecond // the wait never grows beyond this
+)
+
+// TxStats holds the number of transactions retried by the store since it was created
+type TxStats struct {
+	Retries               uint64 `json:"retries"`                // total number of retries
+	SerializationFailures uint64 `json:"serialization_failures"` // retries caused by a 40001 error
+	Deadlocks             uint64 `json:"deadlocks"`              // retries caused by a 40P01 error
+	ExhaustedRetries      uint64 `json:"exhausted_retries"`      // transactions that still failed after the last retry
+}
+
+// TxStats returns the retry counters of the store, they can be exported to a monitoring system
+func (store *SQLStore) TxStats() TxStats {
+	return TxStats{
+		Retries:               store.retries.Load(),
+		SerializationFailures: store.serializationFailures.Load(),
+		Deadlocks:             store.deadlocks.Load(),
+		ExhaustedRetries:      store.exhaustedRetries.Load(),
 	}
 }
 
 // execTx executes a function within a database transaction
-func (store *SQLStore) execTx(ctx context.Context, fn func(queries *Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, nil) // we set the TxOptions to nil so that
-	// we can is the default isolation level is used for the transaction
+// The options declare the isolation level of the transaction and whether it is read only,
+// when they are nil, the default options of the store are used
+// If the transaction fails with a serialization failure or a deadlock, it is retried with the same function
+// so the function must not have side effects outside the transaction
+func (store *SQLStore) execTx(ctx context.Context, opts *sql.TxOptions, fn func(queries *Queries) error) error {
+	if opts == nil {
+		opts = store.defaultTxOptions
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := store.runTx(ctx, opts, fn)
+		if err == nil || !isRetryableTxError(err) {
+			return err
+		}
+
+		if attempt == maxTxRetries {
+			store.exhaustedRetries.Add(1)
+			return err
+		}
+
+		store.retries.Add(1)
+		if isSerializationFailure(err) {
+			store.serializationFailures.Add(1)
+		} else {
+			store.deadlocks.Add(1)
+		}
+
+		// if the request is canceled while we are waiting, we stop retrying
+		if err := sleepContext(ctx, txRetryDelay(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// runTx runs the function once within a database transaction
+func (store *SQLStore) runTx(ctx context.Context, opts *sql.TxOptions, fn func(queries *Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, opts) // when the TxOptions are nil
+	// the default isolation level of the database is used for the transaction
 	if err != nil {
 		return err
 	}
@@ -135,27 +343,65 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(queries *Queries) err
 		// if there is an error we roll back the transaction
 		if rbErr := tx.Rollback(); rbErr != nil {
 			// if the rollback return an error, we return both the transaction and rollback error combined
-			return fmt.Errorf("tx error: %v, rb err: %v", err, rbErr)
+			// the transaction error is wrapped so that the callers can still check it with errors.Is
+			return fmt.Errorf("tx error: %w, rb err: %v", err, rbErr)
 		}
 		return err // return the transaction error
 	}
 	return tx.Commit() // this will return nil or an error in case it fails to commit
 }
 
+// txRetryDelay returns how long to wait before the next retry
+// This is an exponential backoff with full jitter: a random wait between 0 and base * 2^attempt
+func txRetryDelay(attempt int) time.Duration {
+	delay := txRetryMaxDelay
+	if attempt < 16 && txRetryBaseDelay<<attempt < txRetryMaxDelay {
+		delay = txRetryBaseDelay << attempt
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// sleepContext waits for the given duration, or returns the error of the context if it is done before
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // TransferTxParams defines the input parameters for the transfer transaction
 type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	// the amount must be in the currency of the sender's account
+	Amount money.Money `json:"amount"`
+	// the user who asks for the transfer, he is saved with the pending transfer when it needs an approval
+	RequestedBy string `json:"requested_by"`
+	// Idempotency is optional, when it is set the transfer is only performed once for the same key
+	Idempotency *IdempotencyParams `json:"-"`
 }
 
 // TransferTxResult defines the result of the transfer transaction
 type TransferTxResult struct {
-	Transfer    Transfer `json:"transfer"`     // the Transfer struct define in models.go
-	FromAccount Account  `json:"from_account"` // the Account of the sender after the transaction is performed
-	ToAccount   Account  `json:"to_account"`   // the Account of the receiver after the transaction is performed
-	FromEntry   Entry    `json:"from_entry"`   // the Entry that records that money is moving out
-	ToEntry     Entry    `json:"to_entry"`     // the Entry that records that money is moving in
+	Transfer     Transfer     `json:"transfer"`      // the Transfer struct define in models.go
+	FromAccount  Account      `json:"from_account"`  // the Account of the sender after the transaction is performed
+	ToAccount    Account      `json:"to_account"`    // the Account of the receiver after the transaction is performed
+	JournalEntry JournalEntry `json:"journal_entry"` // the JournalEntry that records the transfer in the ledger
+	FromPosting  Posting      `json:"from_posting"`  // the Posting that records that money is moving out, with the fee
+	ToPosting    Posting      `json:"to_posting"`    // the Posting that records that money is moving in
+	// the Posting that credits the fee to the fees system account, nil if the transfer is free
+	// the fee itself is saved with the Transfer
+	FeePosting *Posting `json:"fee_posting,omitempty"`
+	// the pending transfer that was executed, nil if the transfer didn't need an approval
+	// When the transfer needs an approval, only this is set, and nothing else is done until a banker approves it
+	PendingTransfer *PendingTransfer `json:"pending_transfer,omitempty"`
+	// the hold that was captured by the transfer, nil if the transfer didn't come from a hold
+	Hold *Hold `json:"hold,omitempty"`
 }
 
 // this variable will be used for the context key
@@ -165,17 +411,156 @@ type TransferTxResult struct {
 var txKey = struct{}{} // the 2nd bracket means that we are creating a new empty object of type struct{}
 
 // TransferTx performs a money transfer from one account to another
-// It creates a transfer record, add account entries, and update accounts' balance within a single database transaction
+// It creates a transfer record and posts its journal entry, which updates the accounts' balance, within a single database transaction
+// The amount is in the currency of the sender, if the receiver uses another currency
+// he is credited with the amount converted with the rate of the store's rate provider
+// The sender also pays the fee of the store's fee schedule, it goes to the fees system account
+// ErrLimitExceeded is returned if the transfer would go beyond one of the velocity limits of the sender
+// A transfer above the approval threshold of its currency is not executed, only its PendingTransfer is returned
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+	return store.transferTx(ctx, arg, nil, nil)
+}
+
+// transferApproval is the approval of a pending transfer that is being executed
+type transferApproval struct {
+	pendingTransferID int64
+	reviewer          string // the banker who approved it, he is the one who executes it
+}
+
+// holdCapture is the hold that is being captured by a transfer
+type holdCapture struct {
+	holdID int64
+}
+
+// transferTx performs the transfer of TransferTx
+// When approval is nil, the transfer is new and it may need an approval
+// Otherwise, it executes the approved pending transfer, within the same transaction as the transfer
+// When capture is set, the transfer captures that much of the hold, see CaptureHoldTx
+func (store *SQLStore) transferTx(ctx context.Context, arg TransferTxParams, approval *transferApproval, capture *holdCapture) (TransferTxResult, error) {
 	var result TransferTxResult // empty result that will get populated later
 
-	err := store.execTx(ctx, func(q *Queries) error {
+	rate, err := store.exchangeRate(ctx, arg.FromAccountID, arg.ToAccountID)
+	if err != nil {
+		return result, err
+	}
+	// the sender is debited with the amount, and the receiver is credited with the converted amount
+	// fx checks that the amount is in the currency of the sender
+	credit, err := rate.Convert(arg.Amount)
+	if err != nil {
+		return result, err
+	}
+
+	// the fee is also computed before the transaction, since it doesn't depend on the balances
+	// it is in the currency of the sender, who is debited with the amount and the fee together
+	transferFee, err := store.transferFee(ctx, arg.Amount, credit.Currency)
+	if err != nil {
+		return result, err
+	}
+	total, err := arg.Amount.Add(transferFee)
+	if err != nil {
+		return result, err
+	}
+	debit, err := total.Neg()
+	if err != nil {
+		return result, err
+	}
+
+	// the balances are only changed through row locks, so read committed is enough, see readCommittedTx
+	// it is also needed by systemAccount, which must see a system account created by a concurrent transaction
+	err = store.execTx(ctx, readCommittedTx, func(q *Queries) error {
 		// This is where we define the callback function that we pass as our db transaction
 		// All db operations must be done within this single transaction
 		// So the callback function will perform all those operations
 
 		var err error
 
+		// the idempotency key is taken first, so a duplicate request stops here before moving any money
+		if err = beginIdempotentRequest(ctx, q, arg.Idempotency); err != nil {
+			return err
+		}
+
+		// An approved pending transfer is locked before the accounts, like when it was approved
+		// Two bankers approving it at the same time are serialized here, and the second one sees it executed
+		if approval != nil {
+			pending, err := q.GetPendingTransferForUpdate(ctx, approval.pendingTransferID)
+			if err != nil {
+				return err
+			}
+			if pending.Status != utils.ApprovedTransferStatus {
+				return fmt.Errorf("%w: pending transfer [%d] is %s", ErrPendingTransferNotPending, pending.ID, pending.Status)
+			}
+		}
+
+		// A captured hold is also locked before the accounts, so two captures of the same hold are serialized here
+		// and the second one sees what the first one captured
+		var hold Hold
+		if capture != nil {
+			hold, err = lockCapturableHold(ctx, q, capture.holdID, arg.Amount)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Before moving any money, we lock both accounts and check that they are active
+		// They are locked in the order of their IDs, like the balance updates of postJournalEntry, to avoid deadlocks
+		// Since a status change takes the same row lock, an account cannot be frozen or closed
+		// between this check and the end of the transfer
+		fromAccount, toAccount, err := lockActiveAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+		if err != nil {
+			return err
+		}
+
+		// Since the accounts are locked, their balances cannot change until the end of the transaction
+		// So we compute the new balances with the money type first: an amount in the wrong currency,
+		// or a balance that would overflow, is refused before anything is written
+		if _, err = money.New(fromAccount.Balance, fromAccount.Currency).Add(debit); err != nil {
+			return err
+		}
+		if _, err = money.New(toAccount.Balance, toAccount.Currency).Add(credit); err != nil {
+			return err
+		}
+
+		// The captured money was reserved by the hold, so it is taken out of the held amount before it is debited
+		// Only the fee, if any, must come from the available balance of the sender
+		if capture != nil {
+			heldAmount, err := arg.Amount.Neg()
+			if err != nil {
+				return err
+			}
+			if _, err = q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+				Amount: heldAmount.Amount,
+				ID:     arg.FromAccountID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// The velocity limits are checked against the transfers already sent by the sender
+		// Since the sender is locked, no other transfer from the same account can commit in the meantime,
+		// so concurrent transfers are counted one after the other and together they cannot go beyond the limits
+		if err = checkTransferLimits(ctx, q, fromAccount, arg.Amount); err != nil {
+			return err
+		}
+
+		// A large transfer is only saved as pending, it is executed once a banker approves it
+		// The limits were checked above, so the sender knows right away if the transfer can never be made
+		// they are checked again when it is executed
+		// A hold was checked against the threshold when it was created, see CreateHoldTx, so its captures don't need an approval
+		if approval == nil && capture == nil {
+			needsApproval, err := requiresApproval(ctx, q, arg.Amount)
+			if err != nil {
+				return err
+			}
+			if needsApproval {
+				result.PendingTransfer, err = requestPendingTransfer(ctx, q, arg, time.Now().Add(store.approvalExpiry))
+				if err != nil {
+					return err
+				}
+				// the response of the request is the pending transfer, which was only accepted
+				return saveIdempotentResponse(ctx, q, arg.Idempotency.accepted(), result.PendingTransfer)
+			}
+		}
+
 		// the context will hold the transaction name that we can get by calling ctx.Value()
 		// to get the value of the txKey from the context
 		txName := ctx.Value(txKey)
@@ -183,104 +568,1581 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 		// We set the Transfer field of the TransferTxResult with arg information
 		// the output of the transfer will be saved to the appropriate field of the result of type TransferTxResult
 		fmt.Println(txName, "create transfer")
+		// the rate and both amounts are saved with the transfer, so it can be audited later
 		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
+			FromAccountID:  arg.FromAccountID,
+			ToAccountID:    arg.ToAccountID,
+			Amount:         arg.Amount.Amount,
+			FromCurrency:   arg.Amount.Currency,
+			ToCurrency:     credit.Currency,
+			ExchangeRate:   rate.String(),
+			CreditedAmount: credit.Amount,
+			Fee:            transferFee.Amount,
 		})
 		if err != nil {
 			return err
 		}
 
-		// Now we add the two account entries
+		// Now we record the transfer in the ledger
+		// the first posting records that money is moving out, it is negative since money is being deducted from the sender
+		// together with the fee, so the sender has a single posting for the transfer
+		// the second one records that money is moving in, it is positive and in the currency of the receiver
+		lines := []PostingLine{
+			{AccountID: arg.FromAccountID, Amount: debit},
+			{AccountID: arg.ToAccountID, Amount: credit},
+		}
 
-		// entry that records money is moving out
-		fmt.Println(txName, "create entry 1")
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount, // negative since money is being deducted from this account
-		})
-		if err != nil {
-			return err
+		// Between two currencies, each currency must still net to zero,
+		// so the FX system account takes the other side in both of them
+		if debit.Currency != credit.Currency {
+			fxLines, err := fxPostingLines(ctx, q, arg.Amount, credit)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, fxLines...)
+		}
+
+		// the fee is the revenue of the bank, it is credited to the fees system account in the currency of the sender
+		if transferFee.Amount > 0 {
+			feesAccount, err := systemAccount(ctx, q, utils.FeesAccountOwner, transferFee.Currency)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, PostingLine{AccountID: feesAccount.ID, Amount: transferFee})
 		}
 
-		// entry that records money is moving in
-		fmt.Println(txName, "create entry 2")
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount, // positive since the money is being added to this account
+		journal, err := postJournalEntry(ctx, q, JournalEntryParams{
+			Kind:       utils.TransferEntryKind,
+			TransferID: &result.Transfer.ID,
+			Lines:      lines,
 		})
+
+		// The balance of an account cannot go below -overdraft_limit, this is enforced by a CHECK constraint in the database
+		// Only the account being debited can violate it, so this means the sender doesn't have enough money
+		// Since AddAccountBalance locks the row, concurrent transfers are checked one after the other
+		// against the latest balance, so together they can never go beyond the limit
+		// Returning an error here rolls back the transfer and the postings created above
+		if isConstraintViolation(err, balanceConstraint) {
+			return ErrInsufficientFunds
+		}
 		if err != nil {
 			return err
 		}
 
-		// move money out of account1
+		result.JournalEntry = journal.JournalEntry
+		result.FromPosting = journal.Postings[0]
+		result.ToPosting = journal.Postings[1]
+		result.FromAccount = journal.Accounts[arg.FromAccountID]
+		result.ToAccount = journal.Accounts[arg.ToAccountID]
+		if transferFee.Amount > 0 {
+			result.FeePosting = &journal.Postings[len(journal.Postings)-1]
+		}
 
-		// we get the fromAccount record and assign it ot account1 variable
-		// currently when we query for getting the account is run
-		// there is no lock created, and other transaction to get the same account
-		// can be run on that without being blocked
-		// and that is something we don't want
-		// So we will replace the GetAccount call with the GetAccountForUpdate
-		// which implement a lock
+		// the pending transfer is executed in the same transaction, so it is never executed without its transfer
+		if approval != nil {
+			result.PendingTransfer, err = markPendingTransferExecuted(ctx, q, *approval, result.Transfer.ID)
+			if err != nil {
+				return err
+			}
+		}
 
-		// since we add the AddAccountBalance() query
-		// we will remove the GetAccountForUpdate call and replace UpdateAccount
-		// with the AddAccountBalance()
-		/*
-			fmt.Println(txName, "get account 1")
-			account1, err := q.GetAccountForUpdate(ctx, arg.FromAccountID)
+		// the capture is saved in the same transaction, so the hold always matches its transfers
+		if capture != nil {
+			result.Hold, err = recordHoldCapture(ctx, q, hold, arg.Amount, result.Transfer.ID)
 			if err != nil {
 				return err
 			}
-		*/
+		}
+
+		// the response is saved with the transfer, so either both are committed or none of them
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, result)
+	})
+	return result, err
+
+}
+
+// exchangeRate returns the rate to convert an amount from the currency of the first account into the currency of the second
+// It is called before the transaction starts, since a rate provider may be slow and we don't want to hold
+// the locks of the accounts while we wait for it. The currency of an account never changes, so no lock is needed here
+// This also means that a retried transaction uses the same rate
+func (store *SQLStore) exchangeRate(ctx context.Context, fromAccountID int64, toAccountID int64) (fx.Rate, error) {
+	fromAccount, err := store.GetAccount(ctx, fromAccountID)
+	if err != nil {
+		return fx.Rate{}, err
+	}
+	toAccount, err := store.GetAccount(ctx, toAccountID)
+	if err != nil {
+		return fx.Rate{}, err
+	}
 
-		// The solution to the second deadlock issue is that we need to make sure
-		// that both transactions update the accounts balance in the sane order
-		// that is if tx1 update account1 before 2, then tx2 should do the same
+	if fromAccount.Currency == toAccount.Currency {
+		return fx.IdentityRate(fromAccount.Currency), nil
+	}
+	if store.rates == nil {
+		return fx.Rate{}, fmt.Errorf("%w: %s to %s", fx.ErrRateNotFound, fromAccount.Currency, toAccount.Currency)
+	}
+	return store.rates.Rate(ctx, fromAccount.Currency, toAccount.Currency)
+}
 
-		// General advice is that the best way to defend against deadlocks is to avoid
-		// them by making sure that our application always acquire locks in a consistent order
+// transferFee returns the fee of a transfer of amount to an account in toCurrency
+// The transfer is free when the store has no fee schedule
+func (store *SQLStore) transferFee(ctx context.Context, amount money.Money, toCurrency string) (money.Money, error) {
+	if store.fees == nil {
+		return money.New(0, amount.Currency), nil
+	}
+	return store.fees.Fee(ctx, fee.TransferType(amount.Currency, toCurrency), amount)
+}
 
-		// In our case we can make it such that we always update the account with smaller ID first.
+// fxPostingLines returns the postings of the FX system account for an exchange of amount into converted
+// The FX account receives the amount in the first currency, and pays the converted amount in the second one
+// Every transfer between the same currencies updates the same two FX accounts, so they are serialized on their row locks
+func fxPostingLines(ctx context.Context, q *Queries, amount money.Money, converted money.Money) ([]PostingLine, error) {
+	fxFrom, err := systemAccount(ctx, q, utils.FXAccountOwner, amount.Currency)
+	if err != nil {
+		return nil, err
+	}
+	fxTo, err := systemAccount(ctx, q, utils.FXAccountOwner, converted.Currency)
+	if err != nil {
+		return nil, err
+	}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			// In this case we update the fromAccount first
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			// In this case we update the toAccount first
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	paid, err := converted.Neg()
+	if err != nil {
+		return nil, err
+	}
+	return []PostingLine{
+		{AccountID: fxFrom.ID, Amount: amount},
+		{AccountID: fxTo.ID, Amount: paid},
+	}, nil
+}
+
+// lockActiveAccounts locks the accounts in the order of their IDs and checks that they are all active
+// The locked accounts are returned in the order of the arguments
+// ErrAccountNotActive is returned if one of them is frozen or closed
+func lockActiveAccounts(ctx context.Context, q *Queries, accountID1 int64, accountID2 int64) (account1 Account, account2 Account, err error) {
+	if accountID1 < accountID2 {
+		if account1, err = lockActiveAccount(ctx, q, accountID1); err != nil {
+			return
 		}
+		account2, err = lockActiveAccount(ctx, q, accountID2)
+	} else {
+		if account2, err = lockActiveAccount(ctx, q, accountID2); err != nil {
+			return
+		}
+		account1, err = lockActiveAccount(ctx, q, accountID1)
+	}
+	return
+}
+
+// lockActiveAccount locks a single account and checks that it is active
+// ErrAccountNotActive is returned if it is frozen or closed
+func lockActiveAccount(ctx context.Context, q *Queries, accountID int64) (Account, error) {
+	account, err := q.GetAccountForUpdate(ctx, accountID)
+	if err != nil {
+		return account, err
+	}
+	if account.Status != utils.ActiveStatus {
+		return account, fmt.Errorf("%w: account [%d] is %s", ErrAccountNotActive, account.ID, account.Status)
+	}
+	return account, nil
+}
+
+/*
+What are the velocity limits ?
+
+		The regulator asks us to cap how much money can leave an account, so that a stolen account
+		cannot be emptied at once. There are 3 limits, each one can be set or not:
+
+				- max_amount: the amount of a single transfer
+				- max_daily_amount: the total of the transfers sent in the last 24 hours, this one included
+				- max_daily_count: the number of transfers sent in the last 24 hours, this one included
+
+		The bankers set the default limits of each currency, and can override them for a single account.
+		The fee is not part of the limits, since it is not sent to the receiver.
+
+		The limits of an account are also the limits of its owner: a user has at most one open account
+		in each currency (the owner_currency_key index), so everything he sends in a currency leaves that account.
+		The daily limits count the transfers of all his accounts in the currency, the closed ones included,
+		so closing an account and opening a new one doesn't reset them. There is no limit across currencies,
+		since their amounts cannot be added. If a user could open several accounts in the same currency,
+		the limits would need a scope of their own for the user.
+*/
+
+// transferLimits are the velocity limits that apply to an account, a nil limit means that there is no limit
+type transferLimits struct {
+	maxAmount      *money.Amount
+	maxDailyAmount *money.Amount
+	maxDailyCount  *int64
+}
+
+// effectiveTransferLimits returns the limits of an account, each limit that is not set on the account
+// is taken from its currency
+func effectiveTransferLimits(ctx context.Context, q *Queries, account Account) (transferLimits, error) {
+	var limits transferLimits
+
+	currencyLimit, err := q.GetCurrencyTransferLimit(ctx, account.Currency)
+	if err != nil && err != sql.ErrNoRows {
+		return limits, err
+	}
+	limits = transferLimits{
+		maxAmount:      currencyLimit.MaxAmount,
+		maxDailyAmount: currencyLimit.MaxDailyAmount,
+		maxDailyCount:  currencyLimit.MaxDailyCount,
+	}
+
+	accountLimit, err := q.GetAccountTransferLimit(ctx, account.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return limits, err
+	}
+	if accountLimit.MaxAmount != nil {
+		limits.maxAmount = accountLimit.MaxAmount
+	}
+	if accountLimit.MaxDailyAmount != nil {
+		limits.maxDailyAmount = accountLimit.MaxDailyAmount
+	}
+	if accountLimit.MaxDailyCount != nil {
+		limits.maxDailyCount = accountLimit.MaxDailyCount
+	}
+	return limits, nil
+}
+
+// checkTransferLimits checks that a transfer of amount from the account stays within its velocity limits
+// The amount must be in the currency of the account, the daily limits count what its owner sent in that currency
+// A *LimitExceededError is returned for the first limit that the transfer would exceed
+func checkTransferLimits(ctx context.Context, q *Queries, account Account, amount money.Money) error {
+	limits, err := effectiveTransferLimits(ctx, q, account)
+	if err != nil {
 		return err
+	}
+
+	if limits.maxAmount != nil && amount.Amount > *limits.maxAmount {
+		return &LimitExceededError{
+			AccountID: account.ID,
+			Limit:     MaxAmountLimit,
+			Max:       int64(*limits.maxAmount),
+			Requested: int64(amount.Amount),
+		}
+	}
+
+	// the history of the account is only read when there is a daily limit
+	if limits.maxDailyAmount == nil && limits.maxDailyCount == nil {
+		return nil
+	}
+	velocity, err := q.GetTransferVelocity(ctx, GetTransferVelocityParams{
+		Owner:    account.Owner,
+		Currency: account.Currency,
 	})
-	return result, err
+	if err != nil {
+		return err
+	}
+
+	if limits.maxDailyAmount != nil {
+		total, err := money.Amount(velocity.TotalAmount).Add(amount.Amount)
+		if err != nil {
+			return err
+		}
+		if total > *limits.maxDailyAmount {
+			return &LimitExceededError{
+				AccountID: account.ID,
+				Limit:     MaxDailyAmountLimit,
+				Max:       int64(*limits.maxDailyAmount),
+				Used:      velocity.TotalAmount,
+				Requested: int64(amount.Amount),
+			}
+		}
+	}
 
+	if limits.maxDailyCount != nil && velocity.TransferCount >= *limits.maxDailyCount {
+		return &LimitExceededError{
+			AccountID: account.ID,
+			Limit:     MaxDailyCountLimit,
+			Max:       *limits.maxDailyCount,
+			Used:      velocity.TransferCount,
+			Requested: 1,
+		}
+	}
+	return nil
 }
 
-// this function add money to 2 accounts
-// the function will return 3 values:
-// the 1st account object
-// the 2nd account object
-// the potential error
-
-func addMoney(
-	ctx context.Context,
-	q *Queries, // query struct to call AddAccountBalance
-	accountID1 int64, // first account to update
-	amount1 int64, // the amount that needs to be applied to the first account
-	accountID2 int64, // second account to update
-	amount2 int64, // the amount that needs to be applied to the second account
-) (account1 Account, account2 Account, err error) {
-	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID1,
-		Amount: amount1,
-	})
+/*
+How are large transfers approved ?
+
+		A transfer above the approval threshold of its currency is not executed when it is requested.
+		It is saved as a pending transfer, and a banker other than the one who requested it must review it:
+
+				pending ---> approved ---> executed
+				   |            |
+				   |            +--------> expired
+				   +-------> rejected
+				   +-------> expired
+
+		An approved transfer is executed right away, by the banker who approved it. If it cannot be executed,
+		like when the sender doesn't have enough money, it stays approved and can be approved again until it expires.
+		Every step is saved in the audit trail of the pending transfer, with the user who did it.
+		A pending or approved transfer that is not executed in time is marked as expired by the scheduler,
+		see ExpireDuePendingTransferTx, or when it is reviewed too late if the scheduler didn't get to it yet.
+*/
+
+// defaultApprovalExpiry is how long a transfer can wait for its approval, see WithApprovalExpiry
+const defaultApprovalExpiry = 72 * time.Hour
+
+// ReviewPendingTransferTxParams defines the input parameters of the approval and the rejection of a pending transfer
+type ReviewPendingTransferTxParams struct {
+	PendingTransferID int64  `json:"pending_transfer_id"`
+	Reviewer          string `json:"reviewer"` // the banker who reviews it, he cannot be the one who requested it
+	Note              string `json:"note"`     // optional, like the reason of a rejection
+}
+
+// ApprovePendingTransferTx approves a pending transfer and executes it
+// The approval is committed first, so it is kept even if the transfer cannot be executed.
+// In that case the error of TransferTx is returned with the approved PendingTransfer, and the failure is saved in the audit trail
+// ErrSelfReview, ErrPendingTransferNotPending and ErrPendingTransferExpired are returned if it cannot be approved
+func (store *SQLStore) ApprovePendingTransferTx(ctx context.Context, arg ReviewPendingTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	pending, err := store.reviewPendingTransfer(ctx, arg, utils.ApprovedTransferStatus)
+	result.PendingTransfer = &pending
+	if err != nil {
+		return result, err
+	}
+
+	transferResult, err := store.transferTx(ctx, TransferTxParams{
+		FromAccountID: pending.FromAccountID,
+		ToAccountID:   pending.ToAccountID,
+		Amount:        money.New(pending.Amount, pending.Currency),
+		RequestedBy:   pending.RequestedBy,
+	}, &transferApproval{pendingTransferID: pending.ID, reviewer: arg.Reviewer}, nil)
 	if err != nil {
-		return
+		// another banker executed it in the meantime, so this is not a failure of the execution
+		if errors.Is(err, ErrPendingTransferNotPending) {
+			return result, err
+		}
+		_, eventErr := store.CreatePendingTransferEvent(ctx, CreatePendingTransferEventParams{
+			PendingTransferID: pending.ID,
+			Action:            utils.ExecutionFailedTransferAction,
+			Actor:             &arg.Reviewer,
+			Status:            pending.Status,
+			Note:              err.Error(),
+		})
+		if eventErr != nil {
+			return result, fmt.Errorf("execution error: %w, audit err: %v", err, eventErr)
+		}
+		return result, err
+	}
+	return transferResult, nil
+}
+
+// RejectPendingTransferTx rejects a pending transfer, so it will never be executed
+// Only a pending transfer can be rejected, once approved it can only be executed or expire
+func (store *SQLStore) RejectPendingTransferTx(ctx context.Context, arg ReviewPendingTransferTxParams) (PendingTransfer, error) {
+	return store.reviewPendingTransfer(ctx, arg, utils.RejectedTransferStatus)
+}
+
+// reviewPendingTransfer changes the status of a pending transfer to approved or rejected, and saves it in the audit trail
+// A transfer that is already approved can be approved again, its status doesn't change then
+// If the pending transfer has expired, it is marked as expired and ErrPendingTransferExpired is returned
+func (store *SQLStore) reviewPendingTransfer(ctx context.Context, arg ReviewPendingTransferTxParams, status string) (PendingTransfer, error) {
+	var pending PendingTransfer
+	expired := false
+
+	err := store.execTx(ctx, readCommittedTx, func(q *Queries) error {
+		var err error
+
+		pending, err = q.GetPendingTransferForUpdate(ctx, arg.PendingTransferID)
+		if err != nil {
+			return err
+		}
+		if pending.RequestedBy == arg.Reviewer {
+			return ErrSelfReview
+		}
+
+		retry := status == utils.ApprovedTransferStatus && pending.Status == utils.ApprovedTransferStatus
+		if pending.Status != utils.PendingTransferStatus && !retry {
+			return fmt.Errorf("%w: pending transfer [%d] is %s", ErrPendingTransferNotPending, pending.ID, pending.Status)
+		}
+
+		// the expiry must be committed, so the error is only returned after the transaction
+		if !time.Now().Before(pending.ExpiresAt) {
+			pending, err = q.ExpirePendingTransfer(ctx, pending.ID)
+			if err != nil {
+				return err
+			}
+			expired = true
+			return recordPendingTransferEvent(ctx, q, pending, utils.ExpiredTransferAction, nil, "")
+		}
+		if retry {
+			return nil
+		}
+
+		pending, err = q.ReviewPendingTransfer(ctx, ReviewPendingTransferParams{
+			ID:         pending.ID,
+			Status:     status,
+			ReviewedBy: &arg.Reviewer,
+		})
+		if err != nil {
+			return err
+		}
+		// the action has the same name as the status
+		return recordPendingTransferEvent(ctx, q, pending, status, &arg.Reviewer, arg.Note)
+	})
+	if err == nil && expired {
+		err = fmt.Errorf("%w: pending transfer [%d] expired at %s", ErrPendingTransferExpired, pending.ID, pending.ExpiresAt)
 	}
-	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID2,
-		Amount: amount2,
+	return pending, err
+}
+
+// ExpireDuePendingTransferTx claims the next pending or approved transfer that expired at now, and marks it as expired
+// It returns sql.ErrNoRows when no pending transfer has expired, or when the expired ones are being expired by another server
+func (store *SQLStore) ExpireDuePendingTransferTx(ctx context.Context, now time.Time) (PendingTransfer, error) {
+	var pending PendingTransfer
+
+	err := store.execTx(ctx, readCommittedTx, func(q *Queries) error {
+		var err error
+
+		pending, err = q.ClaimExpiredPendingTransfer(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		pending, err = q.ExpirePendingTransfer(ctx, pending.ID)
+		if err != nil {
+			return err
+		}
+		// nobody expired it, so the event has no actor
+		return recordPendingTransferEvent(ctx, q, pending, utils.ExpiredTransferAction, nil, "")
+	})
+	return pending, err
+}
+
+// requiresApproval tells if a transfer of amount must be approved by a banker before it is executed
+// The threshold is the one of the currency of the amount, a transfer of exactly the threshold doesn't need an approval
+func requiresApproval(ctx context.Context, q *Queries, amount money.Money) (bool, error) {
+	limit, err := q.GetCurrencyTransferLimit(ctx, amount.Currency)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return limit.ApprovalThreshold != nil && amount.Amount > *limit.ApprovalThreshold, nil
+}
+
+// requestPendingTransfer saves a transfer that needs an approval, and the request in its audit trail
+func requestPendingTransfer(ctx context.Context, q *Queries, arg TransferTxParams, expiresAt time.Time) (*PendingTransfer, error) {
+	pending, err := q.CreatePendingTransfer(ctx, CreatePendingTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount.Amount,
+		Currency:      arg.Amount.Currency,
+		RequestedBy:   arg.RequestedBy,
+		ExpiresAt:     expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = recordPendingTransferEvent(ctx, q, pending, utils.RequestedTransferAction, &arg.RequestedBy, "")
+	return &pending, err
+}
+
+// markPendingTransferExecuted links an approved pending transfer to its transfer, and saves the execution in its audit trail
+func markPendingTransferExecuted(ctx context.Context, q *Queries, approval transferApproval, transferID int64) (*PendingTransfer, error) {
+	pending, err := q.ExecutePendingTransfer(ctx, ExecutePendingTransferParams{
+		ID:         approval.pendingTransferID,
+		TransferID: &transferID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = recordPendingTransferEvent(ctx, q, pending, utils.ExecutedTransferAction, &approval.reviewer, "")
+	return &pending, err
+}
+
+// recordPendingTransferEvent adds an action to the audit trail of a pending transfer
+// The status saved with it is the current status of the pending transfer, that is after the action
+func recordPendingTransferEvent(ctx context.Context, q *Queries, pending PendingTransfer, action string, actor *string, note string) error {
+	_, err := q.CreatePendingTransferEvent(ctx, CreatePendingTransferEventParams{
+		PendingTransferID: pending.ID,
+		Action:            action,
+		Actor:             actor,
+		Status:            pending.Status,
+		Note:              note,
+	})
+	return err
+}
+
+/*
+How are standing orders executed ?
+
+		A standing order is a transfer that is made again and again on a schedule, like the rent on the 1st of every month.
+		Every server of the bank runs the scheduler, which calls ExecuteDueStandingOrderTx until nothing is due:
+
+				- the next due standing order is claimed with SELECT ... FOR UPDATE SKIP LOCKED,
+				  and its claimed_until is set, so two servers never take the same one at the same time
+				- its run is made with TransferTx, like any other transfer of its owner
+				- the attempt is saved with its outcome, and the standing order moves to its next run
+
+		These are three short transactions: no row stays locked and no connection of the pool is held while the transfer
+		is made, so the scheduler works even with a single connection. The claim is not a lock, it only hides the
+		standing order from the other servers until claimed_until, which is much longer than a transfer.
+
+		The transfer uses an idempotency key made of the standing order and the date of the run. If a server crashes
+		after the transfer is committed, but before the run is saved, the standing order is claimed again once its
+		claim is over, and the next attempt finds the key and saves the transfer that was already made.
+		So a run is never executed twice, even by several servers.
+
+		An attempt that fails is tried again later, and the delay doubles after every attempt.
+		After the last attempt the run is skipped, and the standing order moves to its next run.
+		A transfer above the approval threshold is not a failure: its pending transfer waits for a banker like any other.
+
+		A paused standing order doesn't run. When it is resumed, the runs it missed are recorded as skipped
+		and it moves to its first run at or after now, so three months of rent are not paid in a single poll.
+		The runs missed while the servers were stopped are still made, since the standing order was active.
+*/
+
+// maxStandingOrderRetryDelay is the longest wait between two attempts of a run, the delay never grows beyond it
+const maxStandingOrderRetryDelay = 24 * time.Hour
+
+// StandingOrderRetryPolicy tells how many times a run of a standing order is attempted, and how long to wait in between
+type StandingOrderRetryPolicy struct {
+	MaxAttempts int32         // the attempts of a single run, 1 means that a failed run is never tried again
+	RetryDelay  time.Duration // the wait after the first failed attempt, it doubles after each one
+}
+
+// retryDelay returns how long to wait after a failed attempt, the first attempt is 1
+func (policy StandingOrderRetryPolicy) retryDelay(attempt int32) time.Duration {
+	delay := policy.RetryDelay
+	for i := int32(1); i < attempt && delay < maxStandingOrderRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxStandingOrderRetryDelay {
+		delay = maxStandingOrderRetryDelay
+	}
+	return delay
+}
+
+// ExecuteStandingOrderTxParams defines the input parameters of the execution of a due standing order
+type ExecuteStandingOrderTxParams struct {
+	Now         time.Time                // the standing orders due at this time can run
+	RetryPolicy StandingOrderRetryPolicy // what to do when the transfer of the run fails
+}
+
+// StandingOrderTxResult defines the result of the execution of a standing order
+type StandingOrderTxResult struct {
+	StandingOrder StandingOrder `json:"standing_order"` // the standing order after the attempt, with its next run
+	// the attempt that was made, nil if the standing order had already ended and was only completed
+	Run *StandingOrderRun `json:"run,omitempty"`
+}
+
+// standingOrderClaimLease is how long a claimed standing order is hidden from the other servers while its run is made
+// It is much longer than a transfer, so a claim is only over before its run is saved when the server has stopped
+const standingOrderClaimLease = 5 * time.Minute
+
+// ExecuteDueStandingOrderTx claims the next due standing order, makes its transfer and saves the outcome of the attempt
+// It returns sql.ErrNoRows when no standing order is due, or when the due ones are being executed by another server
+// A transfer that fails is not an error: it is saved as the outcome of the attempt, the error is only about the claim
+func (store *SQLStore) ExecuteDueStandingOrderTx(ctx context.Context, arg ExecuteStandingOrderTxParams) (StandingOrderTxResult, error) {
+	order, completed, err := store.claimDueStandingOrder(ctx, arg.Now)
+	if err != nil || completed {
+		return StandingOrderTxResult{StandingOrder: order}, err
+	}
+
+	transfer, transferErr := store.standingOrderTransfer(ctx, order)
+	if ctx.Err() != nil {
+		// the server is stopping, the run is not saved and another server claims the standing order once its claim is over
+		// the transfer may have been committed just before, the next attempt finds it with its idempotency key
+		return StandingOrderTxResult{}, ctx.Err()
+	}
+
+	return store.saveStandingOrderRun(ctx, arg, order, transfer, transferErr)
+}
+
+// claimDueStandingOrder claims the next due standing order until the end of its lease, and returns it
+// The end of the standing order can be moved before its next run, then there is nothing left to run:
+// it is completed instead of claimed, and the second result is true
+func (store *SQLStore) claimDueStandingOrder(ctx context.Context, now time.Time) (StandingOrder, bool, error) {
+	var order StandingOrder
+	completed := false
+
+	err := store.execTx(ctx, readCommittedTx, func(q *Queries) error {
+		var err error
+		order, err = q.ClaimDueStandingOrder(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		completed = order.EndAt != nil && order.NextRunAt.After(*order.EndAt)
+		if completed {
+			order, err = q.ScheduleStandingOrder(ctx, ScheduleStandingOrderParams{
+				ID:        order.ID,
+				NextRunAt: order.NextRunAt,
+				RunCount:  order.RunCount,
+				Status:    utils.CompletedStandingOrderStatus,
+			})
+			return err
+		}
+
+		claimedUntil := now.Add(standingOrderClaimLease)
+		order, err = q.ClaimStandingOrder(ctx, ClaimStandingOrderParams{
+			ID:           order.ID,
+			ClaimedUntil: &claimedUntil,
+		})
+		return err
+	})
+	return order, completed, err
+}
+
+// saveStandingOrderRun saves the outcome of the attempt of a claimed standing order, and moves it to its next run
+// The standing order is locked again, so a status that its owner changed while the transfer was made is kept
+// If the claim was over and another server claimed the standing order again, that server saves the run instead
+func (store *SQLStore) saveStandingOrderRun(ctx context.Context, arg ExecuteStandingOrderTxParams, claimed StandingOrder,
+	transfer TransferTxResult, transferErr error) (StandingOrderTxResult, error) {
+	var result StandingOrderTxResult
+
+	maxAttempts := arg.RetryPolicy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	err := store.execTx(ctx, readCommittedTx, func(q *Queries) error {
+		order, err := q.GetStandingOrderForUpdate(ctx, claimed.ID)
+		if err != nil {
+			return err
+		}
+		if order.ClaimedUntil == nil || !order.ClaimedUntil.Equal(*claimed.ClaimedUntil) {
+			return fmt.Errorf("standing order [%d] was claimed again before its run was saved", order.ID)
+		}
+
+		attempt := CreateStandingOrderRunParams{
+			StandingOrderID: order.ID,
+			ScheduledFor:    claimed.NextRunAt,
+			Attempt:         claimed.FailedAttempts + 1,
+			MaxAttempts:     maxAttempts,
+		}
+		// by default, the standing order moves to its next run
+		next := nextStandingOrderRun(order)
+
+		// the owner paused and resumed the standing order while the transfer was made, so it has already moved
+		// past the runs it missed, and this run cannot be tried again
+		movedOn := order.RunCount != claimed.RunCount
+		if movedOn {
+			next = ScheduleStandingOrderParams{
+				ID:        order.ID,
+				NextRunAt: order.NextRunAt,
+				RunCount:  order.RunCount,
+				Status:    order.Status,
+			}
+		}
+
+		switch {
+		case transferErr == nil && transfer.PendingTransfer != nil:
+			attempt.Outcome = utils.PendingApprovalRunOutcome
+			attempt.PendingTransferID = &transfer.PendingTransfer.ID
+		case transferErr == nil:
+			attempt.Outcome = utils.SucceededRunOutcome
+			attempt.TransferID = &transfer.Transfer.ID
+		case attempt.Attempt < maxAttempts && !movedOn:
+			// the same run is tried again later, so the standing order doesn't move
+			retryAt := arg.Now.Add(arg.RetryPolicy.retryDelay(attempt.Attempt))
+			attempt.Outcome = utils.RetryingRunOutcome
+			attempt.Error = transferErr.Error()
+			attempt.RetryAt = &retryAt
+			next = ScheduleStandingOrderParams{
+				ID:             order.ID,
+				NextRunAt:      order.NextRunAt,
+				RunCount:       order.RunCount,
+				FailedAttempts: attempt.Attempt,
+				RetryAt:        &retryAt,
+				Status:         order.Status,
+			}
+		default:
+			attempt.Outcome = utils.FailedRunOutcome
+			attempt.Error = transferErr.Error()
+		}
+
+		run, err := q.CreateStandingOrderRun(ctx, attempt)
+		if err != nil {
+			return err
+		}
+		result.Run = &run
+
+		result.StandingOrder, err = q.ScheduleStandingOrder(ctx, next)
+		return err
+	})
+	return result, err
+}
+
+// standingOrderSchedule returns the schedule of a standing order
+func standingOrderSchedule(order StandingOrder) schedule.Schedule {
+	return schedule.Schedule{
+		Frequency: order.Frequency,
+		Interval:  order.IntervalCount,
+		Start:     order.StartAt,
+		End:       order.EndAt,
+	}
+}
+
+// nextStandingOrderRun moves a standing order to the run after its current one
+// It is completed when that run is after its end
+func nextStandingOrderRun(order StandingOrder) ScheduleStandingOrderParams {
+	nextRunAt, ok := standingOrderSchedule(order).Run(order.RunCount + 1)
+	status := order.Status
+	if !ok {
+		status = utils.CompletedStandingOrderStatus
+	}
+	return ScheduleStandingOrderParams{
+		ID:        order.ID,
+		NextRunAt: nextRunAt,
+		RunCount:  order.RunCount + 1,
+		Status:    status,
+	}
+}
+
+// standingOrderTransfer makes the transfer of the next run of a standing order, on behalf of the user who created it
+// The idempotency key of the run makes sure that it is only made once, whatever the number of attempts
+// If the key was already used, the run was made by an attempt that crashed, so what it saved is returned instead
+func (store *SQLStore) standingOrderTransfer(ctx context.Context, order StandingOrder) (TransferTxResult, error) {
+	idempotency := &IdempotencyParams{
+		Username:       order.CreatedBy,
+		Key:            fmt.Sprintf("standing-order:%d:%s", order.ID, order.NextRunAt.UTC().Format(time.RFC3339Nano)),
+		RequestHash:    fmt.Sprintf("standing-order:%d", order.ID),
+		ResponseStatus: http.StatusOK,
+		AcceptedStatus: http.StatusAccepted,
+	}
+
+	result, err := store.transferTx(ctx, TransferTxParams{
+		FromAccountID: order.FromAccountID,
+		ToAccountID:   order.ToAccountID,
+		Amount:        money.New(order.Amount, order.Currency),
+		RequestedBy:   order.CreatedBy,
+		Idempotency:   idempotency,
+	}, nil, nil)
+	if !errors.Is(err, ErrIdempotencyKeyExists) {
+		return result, err
+	}
+
+	key, err := store.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+		Username: idempotency.Username,
+		Key:      idempotency.Key,
+	})
+	if err != nil {
+		return result, err
+	}
+	// the owner may have used the same key for one of his own requests
+	if key.RequestHash != idempotency.RequestHash {
+		return result, fmt.Errorf("%w: %s was used by another request", ErrIdempotencyKeyExists, idempotency.Key)
+	}
+
+	// a transfer that needed an approval only saved its pending transfer
+	if key.ResponseStatus == idempotency.AcceptedStatus {
+		result.PendingTransfer = &PendingTransfer{}
+		err = json.Unmarshal(key.ResponseBody, result.PendingTransfer)
+		return result, err
+	}
+	err = json.Unmarshal(key.ResponseBody, &result)
+	return result, err
+}
+
+// UpdateStandingOrderTxParams defines the changes of a standing order, a field that is not set doesn't change
+type UpdateStandingOrderTxParams struct {
+	ID     int64         `json:"id"`
+	Amount *money.Amount `json:"amount"`
+	EndAt  *time.Time    `json:"end_at"`
+	Status string        `json:"status"` // active or paused, or cancelled to stop it for good
+}
+
+// UpdateStandingOrderTx changes the amount, the end or the status of a standing order
+// The standing order is locked before it is checked, so a run that completes it at the same time is never overwritten
+// ErrStandingOrderClosed is returned if it was already cancelled or completed
+// A paused standing order that is resumed skips the runs it missed, see skipMissedStandingOrderRuns
+func (store *SQLStore) UpdateStandingOrderTx(ctx context.Context, arg UpdateStandingOrderTxParams) (StandingOrder, error) {
+	var order StandingOrder
+
+	err := store.execTx(ctx, readCommittedTx, func(q *Queries) error {
+		var err error
+		order, err = q.GetStandingOrderForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if order.Status == utils.CancelledStandingOrderStatus || order.Status == utils.CompletedStandingOrderStatus {
+			return fmt.Errorf("%w: standing order [%d] is %s", ErrStandingOrderClosed, order.ID, order.Status)
+		}
+
+		update := UpdateStandingOrderParams{
+			ID:     order.ID,
+			Amount: order.Amount,
+			EndAt:  order.EndAt,
+			Status: order.Status,
+		}
+		if arg.Amount != nil {
+			update.Amount = *arg.Amount
+		}
+		if arg.EndAt != nil {
+			update.EndAt = arg.EndAt
+		}
+		if arg.Status != "" {
+			update.Status = arg.Status
+		}
+
+		resumed := order.Status == utils.PausedStandingOrderStatus && update.Status == utils.ActiveStandingOrderStatus
+
+		order, err = q.UpdateStandingOrder(ctx, update)
+		if err != nil || !resumed {
+			return err
+		}
+
+		order, err = skipMissedStandingOrderRuns(ctx, q, order, time.Now())
+		return err
+	})
+	return order, err
+}
+
+// skipMissedStandingOrderRuns moves a resumed standing order to its first run at or after now
+// The runs it missed while it was paused are not made, each of them is recorded as skipped
+// If it is still claimed, its next run is being made by the scheduler, so only the runs after it are skipped
+// The standing order is completed if its schedule ended while it was paused
+func skipMissedStandingOrderRuns(ctx context.Context, q *Queries, order StandingOrder, now time.Time) (StandingOrder, error) {
+	plan := standingOrderSchedule(order)
+
+	runCount := order.RunCount
+	if order.ClaimedUntil != nil && order.ClaimedUntil.After(now) {
+		runCount++
+	}
+	firstSkipped := runCount
+
+	runAt, ok := plan.Run(runCount)
+	for ok && runAt.Before(now) {
+		_, err := q.CreateStandingOrderRun(ctx, CreateStandingOrderRunParams{
+			StandingOrderID: order.ID,
+			ScheduledFor:    runAt,
+			Attempt:         0, // no transfer was tried
+			Outcome:         utils.SkippedRunOutcome,
+		})
+		if err != nil {
+			return order, err
+		}
+		runCount++
+		runAt, ok = plan.Run(runCount)
+	}
+
+	if runCount == firstSkipped {
+		// nothing was missed, the next run is still in the future or it is being made
+		return order, nil
+	}
+
+	status := order.Status
+	if !ok {
+		status = utils.CompletedStandingOrderStatus
+	}
+	return q.SkipStandingOrderRuns(ctx, SkipStandingOrderRunsParams{
+		ID:        order.ID,
+		NextRunAt: runAt,
+		RunCount:  runCount,
+		Status:    status,
+	})
+}
+
+/*
+How do holds work ?
+
+		A hold is a payment in two steps, like a card payment at a hotel: the money is reserved now, and taken later.
+
+				- CreateHoldTx reserves the amount on the account of the payer: it is added to its held amount.
+				  The balance doesn't change, but the available balance (balance - held amount) goes down,
+				  and the CHECK constraint of the overdraft limit is on the available balance.
+				  So a transfer or a withdrawal can never use the reserved money.
+				- CaptureHoldTx takes all or part of the reserved money. Each capture is a normal transfer,
+				  with its journal entry and its fee, and the captured amount leaves the held amount at the same time.
+				  A hold can be captured several times, until nothing is left.
+				- ReleaseHoldTx gives back what was not captured, and the hold can no longer be captured.
+				- A hold that is not captured or released in time expires: the scheduler calls ExpireDueHoldTx,
+				  which gives back what was not captured, like a release.
+
+		The hold is always locked before the accounts, like a pending transfer, so a capture and a release
+		of the same hold are serialized, and the reconciliation checks that the held amount of every account
+		is what its active holds have not captured yet.
+*/
+
+// defaultHoldExpiry is how long a hold reserves its money, see WithHoldExpiry
+const defaultHoldExpiry = 7 * 24 * time.Hour
+
+// CreateHoldTxParams defines the input parameters of a new hold
+type CreateHoldTxParams struct {
+	FromAccountID int64 `json:"from_account_id"` // the account whose money is reserved
+	ToAccountID   int64 `json:"to_account_id"`   // the account that receives the money when it is captured
+	// the amount must be in the currency of the account whose money is reserved
+	Amount    money.Money `json:"amount"`
+	CreatedBy string      `json:"created_by"`
+	// Idempotency is optional, when it is set the hold is only created once for the same key
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// CreateHoldTx reserves money on an account until it is captured, released or it expires
+// Like TransferTx, ErrInsufficientFunds is returned if the available balance would go below the overdraft limit,
+// and ErrLimitExceeded if the amount would go beyond one of the velocity limits of the account
+// The limits are checked again by each capture, since the captures are the transfers that count in them
+// A hold above the approval threshold of its currency is refused with ErrHoldRequiresApproval
+func (store *SQLStore) CreateHoldTx(ctx context.Context, arg CreateHoldTxParams) (Hold, error) {
+	var hold Hold
+
+	err := store.execTx(ctx, readCommittedTx, func(q *Queries) error {
+		if err := beginIdempotentRequest(ctx, q, arg.Idempotency); err != nil {
+			return err
+		}
+
+		// both accounts are locked and must be active, like the accounts of a transfer
+		fromAccount, _, err := lockActiveAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+		if err != nil {
+			return err
+		}
+		if _, err = money.New(fromAccount.HeldAmount, fromAccount.Currency).Add(arg.Amount); err != nil {
+			return err
+		}
+
+		if err = checkTransferLimits(ctx, q, fromAccount, arg.Amount); err != nil {
+			return err
+		}
+		needsApproval, err := requiresApproval(ctx, q, arg.Amount)
+		if err != nil {
+			return err
+		}
+		if needsApproval {
+			return fmt.Errorf("%w: %s", ErrHoldRequiresApproval, arg.Amount)
+		}
+
+		// the available balance is checked by the database, like the balance of a transfer
+		_, err = q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+			Amount: arg.Amount.Amount,
+			ID:     arg.FromAccountID,
+		})
+		if isConstraintViolation(err, balanceConstraint) {
+			return ErrInsufficientFunds
+		}
+		if err != nil {
+			return err
+		}
+
+		hold, err = q.CreateHold(ctx, CreateHoldParams{
+			AccountID:   arg.FromAccountID,
+			ToAccountID: arg.ToAccountID,
+			Amount:      arg.Amount.Amount,
+			Currency:    arg.Amount.Currency,
+			CreatedBy:   arg.CreatedBy,
+			ExpiresAt:   time.Now().Add(store.holdExpiry),
+		})
+		if err != nil {
+			return err
+		}
+
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, hold)
+	})
+	return hold, err
+}
+
+// CaptureHoldTxParams defines the input parameters of the capture of a hold
+type CaptureHoldTxParams struct {
+	HoldID int64 `json:"hold_id"`
+	// the amount to capture, in the currency of the hold
+	// when it is 0, what is left of the hold is captured
+	Amount money.Amount `json:"amount"`
+	// Idempotency is optional, when it is set the capture is only performed once for the same key
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// CaptureHoldTx takes money reserved by a hold, with a transfer from the account of the hold to its receiver
+// The transfer is made by TransferTx, so it has the same fee, conversion and limits as any other transfer,
+// and its result has the hold after the capture. The hold is captured once nothing is left of it
+// ErrHoldNotActive, ErrHoldExpired and ErrHoldAmountExceeded are returned if the hold cannot be captured
+func (store *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (TransferTxResult, error) {
+	// the hold is read before the transaction to build the transfer, it is checked again once it is locked
+	hold, err := store.GetHold(ctx, arg.HoldID)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	amount := arg.Amount
+	if amount == 0 {
+		amount = hold.Amount - hold.CapturedAmount
+	}
+
+	return store.transferTx(ctx, TransferTxParams{
+		FromAccountID: hold.AccountID,
+		ToAccountID:   hold.ToAccountID,
+		Amount:        money.New(amount, hold.Currency),
+		RequestedBy:   hold.CreatedBy,
+		Idempotency:   arg.Idempotency,
+	}, nil, &holdCapture{holdID: hold.ID})
+}
+
+// lockCapturableHold locks a hold and checks that amount can be captured from it
+func lockCapturableHold(ctx context.Context, q *Queries, holdID int64, amount money.Money) (Hold, error) {
+	hold, err := q.GetHoldForUpdate(ctx, holdID)
+	if err != nil {
+		return hold, err
+	}
+	if hold.Status != utils.ActiveHoldStatus {
+		return hold, fmt.Errorf("%w: hold [%d] is %s", ErrHoldNotActive, hold.ID, hold.Status)
+	}
+	if !time.Now().Before(hold.ExpiresAt) {
+		return hold, fmt.Errorf("%w: hold [%d] expired at %s", ErrHoldExpired, hold.ID, hold.ExpiresAt)
+	}
+	if amount.Currency != hold.Currency {
+		return hold, fmt.Errorf("%w: %s vs %s", money.ErrCurrencyMismatch, amount.Currency, hold.Currency)
+	}
+	if remaining := hold.Amount - hold.CapturedAmount; amount.Amount > remaining {
+		return hold, fmt.Errorf("%w: %d requested, %d left on hold [%d]", ErrHoldAmountExceeded, amount.Amount, remaining, hold.ID)
+	}
+	return hold, nil
+}
+
+// recordHoldCapture adds a capture to a locked hold and links it to its transfer
+func recordHoldCapture(ctx context.Context, q *Queries, hold Hold, amount money.Money, transferID int64) (*Hold, error) {
+	captured := hold.CapturedAmount + amount.Amount
+	status := utils.ActiveHoldStatus
+	if captured == hold.Amount {
+		status = utils.CapturedHoldStatus
+	}
+
+	updated, err := q.UpdateHold(ctx, UpdateHoldParams{
+		ID:             hold.ID,
+		CapturedAmount: captured,
+		Status:         status,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = q.CreateHoldCapture(ctx, CreateHoldCaptureParams{
+		HoldID:     hold.ID,
+		TransferID: transferID,
+		Amount:     amount.Amount,
+	})
+	return &updated, err
+}
+
+// ReleaseHoldTx gives back what was not captured of a hold, so the hold can no longer be captured
+// ErrHoldNotActive is returned if the hold was already captured, released or expired
+func (store *SQLStore) ReleaseHoldTx(ctx context.Context, holdID int64) (Hold, error) {
+	var hold Hold
+
+	err := store.execTx(ctx, readCommittedTx, func(q *Queries) error {
+		var err error
+
+		hold, err = q.GetHoldForUpdate(ctx, holdID)
+		if err != nil {
+			return err
+		}
+		if hold.Status != utils.ActiveHoldStatus {
+			return fmt.Errorf("%w: hold [%d] is %s", ErrHoldNotActive, hold.ID, hold.Status)
+		}
+
+		hold, err = closeHold(ctx, q, hold, utils.ReleasedHoldStatus)
+		return err
+	})
+	return hold, err
+}
+
+// ExpireDueHoldTx claims the next active hold that expired at now, and gives back what was not captured
+// It returns sql.ErrNoRows when no hold has expired, or when the expired ones are being expired by another server
+func (store *SQLStore) ExpireDueHoldTx(ctx context.Context, now time.Time) (Hold, error) {
+	var hold Hold
+
+	err := store.execTx(ctx, readCommittedTx, func(q *Queries) error {
+		var err error
+
+		hold, err = q.ClaimExpiredHold(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		hold, err = closeHold(ctx, q, hold, utils.ExpiredHoldStatus)
+		return err
+	})
+	return hold, err
+}
+
+// closeHold releases or expires a locked hold, what it has not captured leaves the held amount of its account
+// The account doesn't need to be active: a frozen account still gets its money back
+func closeHold(ctx context.Context, q *Queries, hold Hold, status string) (Hold, error) {
+	// this is negative, since the money leaves the held amount
+	released := hold.CapturedAmount - hold.Amount
+	if _, err := q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+		Amount: released,
+		ID:     hold.AccountID,
+	}); err != nil {
+		return hold, err
+	}
+
+	return q.UpdateHold(ctx, UpdateHoldParams{
+		ID:             hold.ID,
+		CapturedAmount: hold.CapturedAmount,
+		Status:         status,
+	})
+}
+
+/*
+How is money moved in the ledger ?
+
+		Every movement of money is a journal entry, made of postings. A posting adds an amount to the balance
+		of one account, and the postings of a journal entry must net to zero in each currency.
+		So money is never created or lost: what leaves an account always arrives in another one.
+
+		A transfer of 10 USD from account 1 to account 2 is a journal entry with 2 postings:
+
+				- account 1: -10 USD
+				- account 2: +10 USD
+
+		A transfer of 10 USD to an account in EUR needs 2 more postings, since each currency must net to zero.
+		The FX system account receives the dollars and pays the euros:
+
+				- account 1:       -10 USD
+				- FX USD account:  +10 USD
+				- FX EUR account:   -9 EUR
+				- account 2:        +9 EUR
+
+		A fee of 1 USD on the first transfer is one more posting on the fees system account,
+		and the sender is debited with the amount and the fee together:
+
+				- account 1:         -11 USD
+				- account 2:         +10 USD
+				- fees USD account:   +1 USD
+
+		A deposit of 10 USD in cash is a journal entry with the settlement system account, which stands for
+		the money outside the bank. Its balance goes below zero by what the customers brought in:
+
+				- account 1:                +10 USD
+				- settlement USD account:   -10 USD
+
+		The database checks that the postings net to zero when the transaction commits, see the migrations.
+		postJournalEntry checks it before anything is written, so that a bug is reported with a clear error.
+*/
+
+// PostingLine is one line of a journal entry, its amount is added to the balance of the account
+// The amount must be in the currency of the account
+type PostingLine struct {
+	AccountID int64
+	Amount    money.Money
+}
+
+// JournalEntryParams defines the journal entry posted by postJournalEntry
+type JournalEntryParams struct {
+	Kind       string  // one of the kinds of db/utils/journal_entry_kind.go
+	TransferID *int64  // the transfer recorded by the journal entry, nil if it doesn't come from a transfer
+	CreatedBy  *string // the banker who made the journal entry, nil if it was not made by a banker
+	Lines      []PostingLine
+}
+
+// JournalEntryResult defines the result of postJournalEntry
+type JournalEntryResult struct {
+	JournalEntry JournalEntry
+	Postings     []Posting         // the postings in the order of the lines
+	Accounts     map[int64]Account // the accounts of the lines, after their balance was updated
+}
+
+// postJournalEntry records a journal entry with its postings, and adds the postings to the balances of their accounts
+// It must run inside a transaction, so that the journal entry and the balances are saved together
+// ErrUnbalancedJournalEntry is returned if the lines don't net to zero in each currency
+func postJournalEntry(ctx context.Context, q *Queries, arg JournalEntryParams) (JournalEntryResult, error) {
+	var result JournalEntryResult
+
+	if err := checkJournalEntryBalanced(arg.Lines); err != nil {
+		return result, err
+	}
+
+	var err error
+	result.JournalEntry, err = q.CreateJournalEntry(ctx, CreateJournalEntryParams{
+		Kind:       arg.Kind,
+		TransferID: arg.TransferID,
+		CreatedBy:  arg.CreatedBy,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// an account can appear in several lines, its balance is only updated once with the total of its lines
+	totals := make(map[int64]money.Amount, len(arg.Lines))
+	for _, line := range arg.Lines {
+		posting, err := q.CreatePosting(ctx, CreatePostingParams{
+			JournalEntryID: result.JournalEntry.ID,
+			AccountID:      line.AccountID,
+			Currency:       line.Amount.Currency,
+			Amount:         line.Amount.Amount,
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Postings = append(result.Postings, posting)
+
+		if totals[line.AccountID], err = totals[line.AccountID].Add(line.Amount.Amount); err != nil {
+			return result, err
+		}
+	}
+
+	// General advice is that the best way to defend against deadlocks is to avoid
+	// them by making sure that our application always acquire locks in a consistent order
+	// In our case we always update the account with smaller ID first.
+	// The customer accounts are usually locked before by the caller, in the same order,
+	// so only the system accounts can make us wait here, and they are also taken in the order of their IDs
+	accountIDs := make([]int64, 0, len(totals))
+	for accountID := range totals {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Slice(accountIDs, func(i, j int) bool { return accountIDs[i] < accountIDs[j] })
+
+	result.Accounts = make(map[int64]Account, len(accountIDs))
+	for _, accountID := range accountIDs {
+		account, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     accountID,
+			Amount: totals[accountID],
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Accounts[accountID] = account
+	}
+	return result, nil
+}
+
+// checkJournalEntryBalanced checks that the lines of a journal entry net to zero in each currency
+// A journal entry needs at least two lines, since a single posting can only create or destroy money
+func checkJournalEntryBalanced(lines []PostingLine) error {
+	if len(lines) < 2 {
+		return fmt.Errorf("%w: it has %d posting", ErrUnbalancedJournalEntry, len(lines))
+	}
+
+	totals := make(map[string]money.Amount)
+	for _, line := range lines {
+		total, err := totals[line.Amount.Currency].Add(line.Amount.Amount)
+		if err != nil {
+			return err
+		}
+		totals[line.Amount.Currency] = total
+	}
+
+	for currency, total := range totals {
+		if total != 0 {
+			return fmt.Errorf("%w: the postings in %s add up to %s", ErrUnbalancedJournalEntry, currency, money.New(total, currency))
+		}
+	}
+	return nil
+}
+
+// systemAccount returns the system account of the owner in the given currency, it is created the first time it is needed
+// If a concurrent transaction creates the same account, CreateSystemAccount waits for it to commit,
+// this is why the caller must use read committed: the last GetSystemAccount must see the account committed by the other transaction
+func systemAccount(ctx context.Context, q *Queries, owner string, currency string) (Account, error) {
+	arg := GetSystemAccountParams{
+		Owner:    owner,
+		Currency: currency,
+	}
+
+	account, err := q.GetSystemAccount(ctx, arg)
+	if err != sql.ErrNoRows {
+		return account, err
+	}
+
+	err = q.CreateSystemAccount(ctx, CreateSystemAccountParams{
+		Owner:    owner,
+		Currency: currency,
+	})
+	if err != nil {
+		return account, err
+	}
+	return q.GetSystemAccount(ctx, arg)
+}
+
+// CashTxParams defines the input parameters of a deposit or a withdrawal
+type CashTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// the amount must be positive and in the currency of the account
+	Amount money.Money `json:"amount"`
+	// the banker who makes the deposit or withdrawal, it is recorded on the journal entry
+	CreatedBy string `json:"created_by"`
+	// Idempotency is optional, when it is set the deposit or withdrawal is only performed once for the same key
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// CashTxResult defines the result of a deposit or a withdrawal
+type CashTxResult struct {
+	Account      Account      `json:"account"`       // the Account after the transaction is performed
+	JournalEntry JournalEntry `json:"journal_entry"` // the JournalEntry that records the deposit or withdrawal in the ledger
+	Posting      Posting      `json:"posting"`       // the Posting of the account, the other one is on the settlement account
+}
+
+// DepositTx adds money coming from outside the bank to an account
+// The money is taken from the settlement system account of the currency, so the ledger stays balanced
+func (store *SQLStore) DepositTx(ctx context.Context, arg CashTxParams) (CashTxResult, error) {
+	return store.cashTx(ctx, utils.DepositEntryKind, arg.Amount, arg)
+}
+
+// WithdrawTx takes money out of an account, to be paid outside the bank
+// The money goes to the settlement system account of the currency
+// Like a transfer, ErrInsufficientFunds is returned if the balance would go below the overdraft limit
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg CashTxParams) (CashTxResult, error) {
+	amount, err := arg.Amount.Neg()
+	if err != nil {
+		return CashTxResult{}, err
+	}
+	return store.cashTx(ctx, utils.WithdrawalEntryKind, amount, arg)
+}
+
+// cashTx posts a journal entry between the account and the settlement account
+// The amount is added to the balance of the account, so it is negative for a withdrawal
+// It follows the same steps as TransferTx: the idempotency key, the lock of the account, then the journal entry
+func (store *SQLStore) cashTx(ctx context.Context, kind string, amount money.Money, arg CashTxParams) (CashTxResult, error) {
+	var result CashTxResult
+
+	settled, err := amount.Neg()
+	if err != nil {
+		return result, err
+	}
+
+	// read committed is enough since the balances are only changed through row locks, and systemAccount needs it
+	err = store.execTx(ctx, readCommittedTx, func(q *Queries) error {
+		if err := beginIdempotentRequest(ctx, q, arg.Idempotency); err != nil {
+			return err
+		}
+
+		// the account is locked before the settlement account, like the customer accounts of a transfer,
+		// so an account cannot be frozen or closed in the middle of the deposit
+		account, err := lockActiveAccount(ctx, q, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if _, err = money.New(account.Balance, account.Currency).Add(amount); err != nil {
+			return err
+		}
+
+		settlement, err := systemAccount(ctx, q, utils.SettlementAccountOwner, amount.Currency)
+		if err != nil {
+			return err
+		}
+
+		journal, err := postJournalEntry(ctx, q, JournalEntryParams{
+			Kind:      kind,
+			CreatedBy: &arg.CreatedBy,
+			Lines: []PostingLine{
+				{AccountID: arg.AccountID, Amount: amount},
+				{AccountID: settlement.ID, Amount: settled},
+			},
+		})
+		// the settlement account has no overdraft limit, so only a withdrawal can violate the constraint
+		if isConstraintViolation(err, balanceConstraint) {
+			return ErrInsufficientFunds
+		}
+		if err != nil {
+			return err
+		}
+
+		result.Account = journal.Accounts[arg.AccountID]
+		result.JournalEntry = journal.JournalEntry
+		result.Posting = journal.Postings[0]
+
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, result)
+	})
+	return result, err
+}
+
+// SetOverdraftLimit changes how far below zero the balance of an account can go
+// The update takes the same row lock as the balance updates of TransferTx, so it can never
+// run in the middle of a transfer on the same account
+// If the account is already more overdrawn than the new limit allows, ErrOverdraftLimitTooLow is returned
+func (store *SQLStore) SetOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error) {
+	account, err := store.UpdateAccountOverdraftLimit(ctx, arg)
+	if isConstraintViolation(err, balanceConstraint) {
+		return account, ErrOverdraftLimitTooLow
+	}
+	return account, err
+}
+
+// SetAccountStatus freezes, unfreezes or closes an account
+// The account is locked first, so its status and balance cannot change until the transaction is over
+// A closed account can never change its status again, ErrAccountClosed is returned in that case
+// An account can only be closed with a zero balance, otherwise ErrAccountBalanceNotZero is returned
+func (store *SQLStore) SetAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, readCommittedTx, func(q *Queries) error {
+		var err error
+
+		account, err = q.GetAccountForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if account.Status == utils.ClosedStatus {
+			return ErrAccountClosed
+		}
+
+		account, err = q.UpdateAccountStatus(ctx, arg)
+		// the zero balance is checked by the database, so it holds even if the balance was changed by another query
+		if isConstraintViolation(err, closedAccountConstraint) {
+			return ErrAccountBalanceNotZero
+		}
+		return err
+	})
+	return account, err
+}
+
+/*
+Why do we reconcile the ledger ?
+
+		The balance of an account is updated by AddAccountBalance, and the postings are inserted by CreatePosting.
+		postJournalEntry runs both in the same transaction, but nothing in the database forces them to agree.
+		So a bug, or a query run by hand, could change one without the other and nobody would notice.
+
+		The reconciliation checks the rules of the ledger:
+
+				- the balance of every account is the sum of its postings
+				- every transfer has one journal entry, with a debit of the sender and a credit of the receiver
+				- the postings of every journal entry net to zero in each currency
+				- the held amount of every account is what its active holds have not captured yet
+
+		It only reads the tables, so it never fixes anything: the discrepancies are reported to a human.
+*/
+
+// ReconciliationReport is the result of ReconcileLedger, it is printed as JSON by the reconcile command
+type ReconciliationReport struct {
+	CheckedAt                 time.Time                         `json:"checked_at"`
+	AccountsChecked           int64                             `json:"accounts_checked"`
+	TransfersChecked          int64                             `json:"transfers_checked"`
+	JournalEntriesChecked     int64                             `json:"journal_entries_checked"`
+	BalanceDiscrepancies      []ListBalanceDiscrepanciesRow     `json:"balance_discrepancies"`       // the accounts whose balance is not the sum of their postings
+	TransferDiscrepancies     []ListTransferDiscrepanciesRow    `json:"transfer_discrepancies"`      // the transfers without exactly one debit and one credit
+	JournalEntryDiscrepancies []ListUnbalancedJournalEntriesRow `json:"journal_entry_discrepancies"` // the journal entries that don't net to zero in a currency
+	HoldDiscrepancies         []ListHeldAmountDiscrepanciesRow  `json:"hold_discrepancies"`          // the accounts whose held amount is not what their active holds reserve
+}
+
+// HasDiscrepancies reports whether the ledger is not consistent
+func (report ReconciliationReport) HasDiscrepancies() bool {
+	return len(report.BalanceDiscrepancies) > 0 ||
+		len(report.TransferDiscrepancies) > 0 ||
+		len(report.JournalEntryDiscrepancies) > 0 ||
+		len(report.HoldDiscrepancies) > 0
+}
+
+// ReconcileLedger checks that the balances match the postings, that every transfer has its two postings,
+// that every journal entry is balanced and that the held amounts match the active holds
+// All the queries run in a read only repeatable read transaction, see readOnlySnapshotTx
+// so they see the same snapshot even if transfers are being made while the reconciliation runs
+func (store *SQLStore) ReconcileLedger(ctx context.Context) (ReconciliationReport, error) {
+	var report ReconciliationReport
+
+	err := store.execTx(ctx, readOnlySnapshotTx, func(q *Queries) error {
+		var err error
+
+		// the report is built again from scratch if the transaction is retried
+		report = ReconciliationReport{CheckedAt: time.Now().UTC()}
+
+		if report.AccountsChecked, err = q.CountAccounts(ctx); err != nil {
+			return err
+		}
+		if report.TransfersChecked, err = q.CountTransfers(ctx); err != nil {
+			return err
+		}
+		if report.JournalEntriesChecked, err = q.CountJournalEntries(ctx); err != nil {
+			return err
+		}
+		if report.BalanceDiscrepancies, err = q.ListBalanceDiscrepancies(ctx); err != nil {
+			return err
+		}
+		if report.TransferDiscrepancies, err = q.ListTransferDiscrepancies(ctx); err != nil {
+			return err
+		}
+		if report.JournalEntryDiscrepancies, err = q.ListUnbalancedJournalEntries(ctx); err != nil {
+			return err
+		}
+		report.HoldDiscrepancies, err = q.ListHeldAmountDiscrepancies(ctx)
+		return err
+	})
+	return report, err
+}
+
+// IdempotencyParams identifies a request that must only be processed once
+// The key is chosen by the client and is only unique for a given user
+// The hash of the request is used to detect a key that is reused for a different request
+type IdempotencyParams struct {
+	Username       string
+	Key            string
+	RequestHash    string
+	ResponseStatus int32 // the status code that is returned with the saved response
+	AcceptedStatus int32 // the status code saved instead when the request was only accepted, like a transfer waiting for an approval
+}
+
+// accepted returns the same idempotency params, with the status code of a request that was only accepted
+func (arg *IdempotencyParams) accepted() *IdempotencyParams {
+	if arg == nil {
+		return nil
+	}
+	accepted := *arg
+	accepted.ResponseStatus = arg.AcceptedStatus
+	return &accepted
+}
+
+// CreateAccountTxParams defines the input parameters for the create account transaction
+type CreateAccountTxParams struct {
+	CreateAccountParams
+	// Idempotency is optional, when it is set the account is only created once for the same key
+	Idempotency *IdempotencyParams
+}
+
+// CreateAccountTx creates an account and saves the idempotency key of the request within a single database transaction
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		var err error
+
+		if err = beginIdempotentRequest(ctx, q, arg.Idempotency); err != nil {
+			return err
+		}
+
+		account, err = q.CreateAccount(ctx, arg.CreateAccountParams)
+		if err != nil {
+			return err
+		}
+
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, account)
+	})
+	return account, err
+}
+
+// beginIdempotentRequest saves the idempotency key at the beginning of a transaction
+// If a concurrent transaction holds the same key, postgres makes us wait until it is done
+// If the key was already used, ErrIdempotencyKeyExists is returned and the whole transaction is rolled back
+// Nothing is done when the request has no idempotency key
+func beginIdempotentRequest(ctx context.Context, q *Queries, arg *IdempotencyParams) error {
+	if arg == nil {
+		return nil
+	}
+
+	_, err := q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+		Username:    arg.Username,
+		Key:         arg.Key,
+		RequestHash: arg.RequestHash,
+	})
+	if isUniqueViolation(err, idempotencyKeyConstraint) {
+		return ErrIdempotencyKeyExists
+	}
+	return err
+}
+
+// saveIdempotentResponse stores the response of a request with its idempotency key
+// The response is saved as JSON, the same way the API sends it to the client,
+// so a replay returns exactly the same body
+func saveIdempotentResponse(ctx context.Context, q *Queries, arg *IdempotencyParams, response interface{}) error {
+	if arg == nil {
+		return nil
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.UpdateIdempotencyKeyResponse(ctx, UpdateIdempotencyKeyResponseParams{
+		Username:       arg.Username,
+		Key:            arg.Key,
+		ResponseStatus: arg.ResponseStatus,
+		ResponseBody:   body,
 	})
-	return // this similar to return account1 , account2 , err ; it's just a shortcut
+	return err
 }
@@ -2,9 +2,19 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/fee"
+	"github.com/elmas23/simplebank/fx"
+	"github.com/elmas23/simplebank/money"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
+	"math"
 	"testing"
+	"time"
 )
 
 func TestTransferTx(t *testing.T) {
@@ -14,8 +24,9 @@ func TestTransferTx(t *testing.T) {
 
 	// we create two randoms accounts
 	// we will send money from accounts 1 to 2
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	// both accounts need enough money for all the transfers, since a balance cannot go below zero
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 1000)
 	fmt.Println(">> before:", account1.Balance, account2.Balance)
 
 	// Since for database transaction we have to handle the concurrency carefully
@@ -24,7 +35,7 @@ func TestTransferTx(t *testing.T) {
 
 	// We will run 2 concurrent transfer transactions and each will transfer an amount of 10 from account 1 to 2
 	n := 2
-	amount := int64(10)
+	amount := money.Amount(10)
 
 	// we create the channel for the result and the error
 	errs := make(chan error)               // all 2 errors will be stored here
@@ -44,7 +55,7 @@ func TestTransferTx(t *testing.T) {
 			result, err := store.TransferTx(ctx, TransferTxParams{
 				FromAccountID: account1.ID,
 				ToAccountID:   account2.ID,
-				Amount:        amount,
+				Amount:        money.New(amount, "USD"),
 			})
 
 			// The function returns a result and an error, but we cannot use testify/require to check them right here
@@ -89,29 +100,43 @@ func TestTransferTx(t *testing.T) {
 		// because the Queries object is embedded inside the SQLStore
 		require.NoError(t, err)
 
-		// Next we will check the accounts entries of the result
+		// Next we will check the journal entry and its postings
 
-		// FromEntry
-		fromEntry := result.FromEntry
-		require.NotEmpty(t, fromEntry)
-		require.Equal(t, account1.ID, fromEntry.AccountID)
-		require.Equal(t, -amount, fromEntry.Amount)
-		require.NotZero(t, fromEntry.ID)
-		require.NotZero(t, fromEntry.CreatedAt)
+		journalEntry := result.JournalEntry
+		require.NotZero(t, journalEntry.ID)
+		require.Equal(t, utils.TransferEntryKind, journalEntry.Kind)
+		require.Equal(t, &transfer.ID, journalEntry.TransferID)
 
-		_, err = store.GetEntry(context.Background(), fromEntry.ID)
+		// FromPosting
+		fromPosting := result.FromPosting
+		require.NotEmpty(t, fromPosting)
+		require.Equal(t, account1.ID, fromPosting.AccountID)
+		require.Equal(t, -amount, fromPosting.Amount)
+		require.Equal(t, account1.Currency, fromPosting.Currency)
+		require.Equal(t, journalEntry.ID, fromPosting.JournalEntryID)
+		require.NotZero(t, fromPosting.ID)
+		require.NotZero(t, fromPosting.CreatedAt)
+
+		_, err = store.GetPosting(context.Background(), fromPosting.ID)
 		require.NoError(t, err)
 
-		// ToEntry
-		toEntry := result.ToEntry
-		require.NotEmpty(t, toEntry)
-		require.Equal(t, account2.ID, toEntry.AccountID)
-		require.Equal(t, amount, toEntry.Amount)
-		require.NotZero(t, toEntry.ID)
-		require.NotZero(t, toEntry.CreatedAt)
+		// ToPosting
+		toPosting := result.ToPosting
+		require.NotEmpty(t, toPosting)
+		require.Equal(t, account2.ID, toPosting.AccountID)
+		require.Equal(t, amount, toPosting.Amount)
+		require.Equal(t, account2.Currency, toPosting.Currency)
+		require.Equal(t, journalEntry.ID, toPosting.JournalEntryID)
+		require.NotZero(t, toPosting.ID)
+		require.NotZero(t, toPosting.CreatedAt)
+
+		_, err = store.GetPosting(context.Background(), toPosting.ID)
+		require.NoError(t, err)
 
-		_, err = store.GetEntry(context.Background(), toEntry.ID)
+		// between two accounts of the same currency, the journal entry only has these two postings
+		postings, err := store.ListJournalEntryPostings(context.Background(), journalEntry.ID)
 		require.NoError(t, err)
+		require.Equal(t, []Posting{fromPosting, toPosting}, postings)
 
 		// We are going to use a test drive development approach
 		// to add the part for updating the account
@@ -157,8 +182,8 @@ func TestTransferTx(t *testing.T) {
 	fmt.Println(">> after:", updatedAccount1.Balance, updatedAccount2.Balance)
 	// after n transactions, the balance of the sender account must decrease by n*amount
 	// after n transactions, the balance of the receiver account must increase n*amount
-	require.Equal(t, account1.Balance-int64(n)*amount, updatedAccount1.Balance)
-	require.Equal(t, account2.Balance+int64(n)*amount, updatedAccount2.Balance)
+	require.Equal(t, account1.Balance-money.Amount(n)*amount, updatedAccount1.Balance)
+	require.Equal(t, account2.Balance+money.Amount(n)*amount, updatedAccount2.Balance)
 }
 
 func TestTransferTxDeadlock(t *testing.T) {
@@ -168,8 +193,9 @@ func TestTransferTxDeadlock(t *testing.T) {
 
 	// we create two randoms accounts
 	// we will send money from accounts 1 to 2
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	// both accounts need enough money for all the transfers, since a balance cannot go below zero
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 1000)
 	fmt.Println(">> before:", account1.Balance, account2.Balance)
 
 	// Here we are testing another potential source of deadlock for our transaction
@@ -195,7 +221,7 @@ func TestTransferTxDeadlock(t *testing.T) {
 	// half will transfer an amount of 10 from account 1 to 2
 	// and the other half will transfer an amount of 10 from account 2 to  1
 	n := 10
-	amount := int64(10)
+	amount := money.Amount(10)
 
 	// we create the channel for the error
 	errs := make(chan error) // all 2 errors will be stored here
@@ -222,7 +248,7 @@ func TestTransferTxDeadlock(t *testing.T) {
 			_, err := store.TransferTx(ctx, TransferTxParams{
 				FromAccountID: fromAccountID, // now we use it here for our TransferTxParams
 				ToAccountID:   toAccountID,   // now we use it here for our TransferTxParams
-				Amount:        amount,
+				Amount:        money.New(amount, "USD"),
 			})
 
 			// The function returns a result (not needed here) and an error, but we cannot use testify/require to check them right here
@@ -260,3 +286,785 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	// the sender only has 10, so he cannot send 20
+	account1 := createRandomAccountWithBalance(t, 10)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(20, "USD"),
+	})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrInsufficientFunds))
+	require.Empty(t, result.FromAccount)
+	require.Empty(t, result.ToAccount)
+
+	// the whole transaction is rolled back, so nothing has changed
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+
+	postings, err := store.ListPostings(context.Background(), ListPostingsParams{
+		AccountID: account1.ID,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Empty(t, postings)
+}
+
+func TestTransferTxOverdraftLimit(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 0)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	account1, err := store.SetOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: 50,
+	})
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(50), account1.OverdraftLimit)
+
+	// the balance can go down to -overdraft_limit
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(50, "USD"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(-50), result.FromAccount.Balance)
+
+	// but not any further
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(1, "USD"),
+	})
+	require.True(t, errors.Is(err, ErrInsufficientFunds))
+
+	// the limit cannot be lowered below the current overdraft
+	_, err = store.SetOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: 10,
+	})
+	require.True(t, errors.Is(err, ErrOverdraftLimitTooLow))
+}
+
+func TestTransferTxExchangeRate(t *testing.T) {
+	rates, err := fx.NewStaticProvider("USD", map[string]string{"EUR": "0.92"})
+	require.NoError(t, err)
+	store := NewStore(testDB, WithRateProvider(rates))
+
+	account1 := createRandomAccountWithCurrency(t, 1000, "USD")
+	account2 := createRandomAccountWithCurrency(t, 0, "EUR")
+
+	// the sender pays in USD and the receiver gets EUR
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(99, "USD"),
+	})
+	require.NoError(t, err)
+
+	// 99 * 0.92 = 91.08, the receiver is credited 91
+	transfer := result.Transfer
+	require.Equal(t, money.Amount(99), transfer.Amount)
+	require.Equal(t, money.Amount(91), transfer.CreditedAmount)
+	require.Equal(t, "USD", transfer.FromCurrency)
+	require.Equal(t, "EUR", transfer.ToCurrency)
+	require.Equal(t, "0.9200000000", transfer.ExchangeRate)
+
+	require.Equal(t, money.Amount(-99), result.FromPosting.Amount)
+	require.Equal(t, money.Amount(91), result.ToPosting.Amount)
+	require.Equal(t, money.Amount(901), result.FromAccount.Balance)
+	require.Equal(t, money.Amount(91), result.ToAccount.Balance)
+
+	// the FX system account takes the other side in each currency, so both currencies net to zero
+	postings, err := store.ListJournalEntryPostings(context.Background(), result.JournalEntry.ID)
+	require.NoError(t, err)
+	require.Len(t, postings, 4)
+
+	totals := make(map[string]money.Amount)
+	fxAmounts := make(map[string]money.Amount)
+	for _, posting := range postings {
+		totals[posting.Currency] += posting.Amount
+		if posting.AccountID != account1.ID && posting.AccountID != account2.ID {
+			fxAccount, err := store.GetAccount(context.Background(), posting.AccountID)
+			require.NoError(t, err)
+			require.Equal(t, utils.FXAccountOwner, fxAccount.Owner)
+			require.Equal(t, posting.Currency, fxAccount.Currency)
+			fxAmounts[posting.Currency] = posting.Amount
+		}
+	}
+	require.Equal(t, map[string]money.Amount{"USD": 0, "EUR": 0}, totals)
+	require.Equal(t, map[string]money.Amount{"USD": 99, "EUR": -91}, fxAmounts)
+
+	// the audit data is saved with the transfer
+	savedTransfer, err := store.GetTransfer(context.Background(), transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, transfer.ExchangeRate, savedTransfer.ExchangeRate)
+	require.Equal(t, transfer.CreditedAmount, savedTransfer.CreditedAmount)
+
+	// without a rate, nothing is transferred
+	account3 := createRandomAccountWithCurrency(t, 0, "CAD")
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account3.ID,
+		Amount:        money.New(10, "USD"),
+	})
+	require.ErrorIs(t, err, fx.ErrRateNotFound)
+
+	// and a store without a rate provider only transfers money between accounts of the same currency
+	_, err = NewStore(testDB).TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(10, "USD"),
+	})
+	require.ErrorIs(t, err, fx.ErrRateNotFound)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(901), updatedAccount1.Balance)
+}
+
+func TestTransferTxFee(t *testing.T) {
+	fees, err := fee.NewStaticSchedule([]fee.Rule{{Currency: "USD", Flat: 10, Percent: "1"}})
+	require.NoError(t, err)
+	store := NewStore(testDB, WithFeeSchedule(fees))
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 0)
+
+	// the fee is 10 + 1% of 100, the sender pays it on top of the amount
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(100, "USD"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(100), result.Transfer.Amount)
+	require.Equal(t, money.Amount(11), result.Transfer.Fee)
+
+	require.Equal(t, money.Amount(-111), result.FromPosting.Amount)
+	require.Equal(t, money.Amount(100), result.ToPosting.Amount)
+	require.Equal(t, money.Amount(889), result.FromAccount.Balance)
+	require.Equal(t, money.Amount(100), result.ToAccount.Balance)
+
+	// the fee is credited to the fees system account, in the same journal entry
+	require.NotNil(t, result.FeePosting)
+	require.Equal(t, result.JournalEntry.ID, result.FeePosting.JournalEntryID)
+	require.Equal(t, money.Amount(11), result.FeePosting.Amount)
+
+	feesAccount, err := store.GetAccount(context.Background(), result.FeePosting.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, utils.FeesAccountOwner, feesAccount.Owner)
+	require.Equal(t, "USD", feesAccount.Currency)
+
+	savedTransfer, err := store.GetTransfer(context.Background(), result.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(11), savedTransfer.Fee)
+
+	report, err := store.ReconcileLedger(context.Background())
+	require.NoError(t, err)
+	requireNoTransferDiscrepancy(t, report, result.Transfer.ID)
+	requireNoBalanceDiscrepancy(t, report, account1.ID)
+
+	// the sender must be able to pay the amount and the fee: 880 + 18 is more than 889
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(880, "USD"),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// without a fee schedule, the transfers are free
+	result, err = NewStore(testDB).TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(100, "USD"),
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Transfer.Fee)
+	require.Nil(t, result.FeePosting)
+	require.Equal(t, money.Amount(-100), result.FromPosting.Amount)
+}
+
+func TestTransferTxOverflow(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccountWithBalance(t, math.MaxInt64-5)
+
+	// the balance of the receiver would overflow, so nothing is written
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(10, "USD"),
+	})
+	require.ErrorIs(t, err, money.ErrOverflow)
+
+	// and an amount in another currency than the one of the sender is refused
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(1, "EUR"),
+	})
+	require.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestTransferTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	_, err := store.SetAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account2.ID,
+		Status: utils.FrozenStatus,
+	})
+	require.NoError(t, err)
+
+	// a frozen account can neither send nor receive money
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(10, "USD"),
+	})
+	require.True(t, errors.Is(err, ErrAccountNotActive))
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        money.New(10, "USD"),
+	})
+	require.True(t, errors.Is(err, ErrAccountNotActive))
+
+	// once unfrozen, the transfer goes through
+	_, err = store.SetAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account2.ID,
+		Status: utils.ActiveStatus,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(10, "USD"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(10), result.ToAccount.Balance)
+}
+
+func TestDepositTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccountWithBalance(t, 0)
+	banker := createRandomUser(t)
+
+	result, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    money.New(100, "USD"),
+		CreatedBy: banker.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(100), result.Account.Balance)
+
+	require.Equal(t, utils.DepositEntryKind, result.JournalEntry.Kind)
+	require.Nil(t, result.JournalEntry.TransferID)
+	require.Equal(t, &banker.Username, result.JournalEntry.CreatedBy) // the banker who made the deposit
+	require.Equal(t, account.ID, result.Posting.AccountID)
+	require.Equal(t, money.Amount(100), result.Posting.Amount)
+
+	// the other side of the deposit is the settlement account of the currency
+	postings, err := store.ListJournalEntryPostings(context.Background(), result.JournalEntry.ID)
+	require.NoError(t, err)
+	require.Len(t, postings, 2)
+
+	settlement, err := store.GetAccount(context.Background(), postings[1].AccountID)
+	require.NoError(t, err)
+	require.Equal(t, utils.SettlementAccountOwner, settlement.Owner)
+	require.Equal(t, "USD", settlement.Currency)
+	require.Equal(t, money.Amount(-100), postings[1].Amount)
+}
+
+func TestWithdrawTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccountWithBalance(t, 0)
+	banker := createRandomUser(t)
+
+	_, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    money.New(100, "USD"),
+		CreatedBy: banker.Username,
+	})
+	require.NoError(t, err)
+
+	result, err := store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    money.New(30, "USD"),
+		CreatedBy: banker.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(70), result.Account.Balance)
+	require.Equal(t, utils.WithdrawalEntryKind, result.JournalEntry.Kind)
+	require.Equal(t, money.Amount(-30), result.Posting.Amount)
+
+	// the balance is the sum of the postings, so the account reconciles
+	report, err := store.ReconcileLedger(context.Background())
+	require.NoError(t, err)
+	requireNoBalanceDiscrepancy(t, report, account.ID)
+
+	// like a transfer, a withdrawal cannot go below the overdraft limit
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    money.New(71, "USD"),
+		CreatedBy: banker.Username,
+	})
+	require.True(t, errors.Is(err, ErrInsufficientFunds))
+
+	updatedAccount, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(70), updatedAccount.Balance)
+}
+
+func TestDepositTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccountWithBalance(t, 0)
+	banker := createRandomUser(t)
+
+	_, err := store.SetAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account.ID,
+		Status: utils.FrozenStatus,
+	})
+	require.NoError(t, err)
+
+	_, err = store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    money.New(10, "USD"),
+		CreatedBy: banker.Username,
+	})
+	require.True(t, errors.Is(err, ErrAccountNotActive))
+}
+
+func TestSetAccountStatusClose(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccountWithBalance(t, 10)
+
+	// an account with money on it cannot be closed
+	_, err := store.SetAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account.ID,
+		Status: utils.ClosedStatus,
+	})
+	require.True(t, errors.Is(err, ErrAccountBalanceNotZero))
+
+	_, err = store.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: -10,
+	})
+	require.NoError(t, err)
+
+	closedAccount, err := store.SetAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account.ID,
+		Status: utils.ClosedStatus,
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.ClosedStatus, closedAccount.Status)
+
+	// closing is final
+	_, err = store.SetAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account.ID,
+		Status: utils.ActiveStatus,
+	})
+	require.True(t, errors.Is(err, ErrAccountClosed))
+
+	// the closed account is kept, and the owner can open a new one in the same currency
+	newAccount, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account.Owner,
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, account.ID, newAccount.ID)
+
+	_, err = store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+}
+
+func TestTransferTxConcurrentOverdraft(t *testing.T) {
+	store := NewStore(testDB)
+
+	// the sender can spend 100 in total: 40 on its balance and 60 on its overdraft
+	account1 := createRandomAccountWithBalance(t, 40)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	_, err := store.SetOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: 60,
+	})
+	require.NoError(t, err)
+
+	// 10 concurrent transfers of 30 would need 300, only 3 of them can succeed
+	n := 10
+	amount := money.Amount(30)
+
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        money.New(amount, "USD"),
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.True(t, errors.Is(err, ErrInsufficientFunds))
+	}
+	require.Equal(t, 3, succeeded)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, money.Amount(40-3*30), updatedAccount1.Balance)
+	require.True(t, updatedAccount1.Balance >= -updatedAccount1.OverdraftLimit)
+}
+
+func TestAddAccountBalanceCannotOverdraw(t *testing.T) {
+	// the constraint is enforced by the database, so even a direct update cannot overdraw an account
+	account := createRandomAccountWithBalance(t, 10)
+
+	_, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: -11,
+	})
+	require.Error(t, err)
+	require.True(t, isConstraintViolation(err, balanceConstraint))
+}
+
+func TestTransferTxIdempotency(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 1000)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(10, "USD"),
+		Idempotency: &IdempotencyParams{
+			Username:       account1.Owner,
+			Key:            utils.GenerateRandomString(16),
+			RequestHash:    utils.GenerateRandomString(64),
+			ResponseStatus: 200,
+		},
+	}
+
+	// the same request is sent concurrently, as a client retrying on a timeout would do
+	n := 5
+	errs := make(chan error)
+	results := make(chan TransferTxResult)
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := store.TransferTx(context.Background(), arg)
+			errs <- err
+			results <- result
+		}()
+	}
+
+	var result TransferTxResult
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		r := <-results
+		if err == nil {
+			succeeded++
+			result = r
+			continue
+		}
+		require.True(t, errors.Is(err, ErrIdempotencyKeyExists))
+	}
+	// only one of them moves money
+	require.Equal(t, 1, succeeded)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-10, updatedAccount1.Balance)
+
+	// the response is saved with the key
+	idempotencyKey, err := store.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: arg.Idempotency.Username,
+		Key:      arg.Idempotency.Key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, arg.Idempotency.RequestHash, idempotencyKey.RequestHash)
+	require.Equal(t, int32(200), idempotencyKey.ResponseStatus)
+
+	var savedResult TransferTxResult
+	err = json.Unmarshal(idempotencyKey.ResponseBody, &savedResult)
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.ID, savedResult.Transfer.ID)
+}
+
+func TestTransferTxIdempotencyRollback(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 0)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(10, "USD"),
+		Idempotency: &IdempotencyParams{
+			Username:       account1.Owner,
+			Key:            utils.GenerateRandomString(16),
+			RequestHash:    utils.GenerateRandomString(64),
+			ResponseStatus: 200,
+		},
+	}
+
+	// a failed transfer doesn't keep the key, so the client can retry once it has enough money
+	_, err := store.TransferTx(context.Background(), arg)
+	require.True(t, errors.Is(err, ErrInsufficientFunds))
+
+	_, err = store.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: arg.Idempotency.Username,
+		Key:      arg.Idempotency.Key,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreateAccountTxIdempotency(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	arg := CreateAccountTxParams{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  0,
+			Currency: utils.GenerateCurrency(),
+		},
+		Idempotency: &IdempotencyParams{
+			Username:       user.Username,
+			Key:            utils.GenerateRandomString(16),
+			RequestHash:    utils.GenerateRandomString(64),
+			ResponseStatus: 200,
+		},
+	}
+
+	account, err := store.CreateAccountTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, account.Owner)
+
+	_, err = store.CreateAccountTx(context.Background(), arg)
+	require.True(t, errors.Is(err, ErrIdempotencyKeyExists))
+
+	idempotencyKey, err := store.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: user.Username,
+		Key:      arg.Idempotency.Key,
+	})
+	require.NoError(t, err)
+
+	var savedAccount Account
+	err = json.Unmarshal(idempotencyKey.ResponseBody, &savedAccount)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, savedAccount.ID)
+}
+
+func TestExecTxRetry(t *testing.T) {
+	store := NewStore(testDB).(*SQLStore)
+
+	// the callback fails twice with errors that postgres returns for concurrent transactions, then succeeds
+	failures := []error{
+		&pq.Error{Code: "40001"},
+		&pq.Error{Code: "40P01"},
+	}
+
+	calls := 0
+	err := store.execTx(context.Background(), nil, func(q *Queries) error {
+		calls++
+		if calls <= len(failures) {
+			return failures[calls-1]
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+
+	stats := store.TxStats()
+	require.Equal(t, uint64(2), stats.Retries)
+	require.Equal(t, uint64(1), stats.SerializationFailures)
+	require.Equal(t, uint64(1), stats.Deadlocks)
+	require.Zero(t, stats.ExhaustedRetries)
+}
+
+func TestExecTxRetryExhausted(t *testing.T) {
+	store := NewStore(testDB).(*SQLStore)
+
+	calls := 0
+	err := store.execTx(context.Background(), nil, func(q *Queries) error {
+		calls++
+		return &pq.Error{Code: "40001"}
+	})
+	require.True(t, isSerializationFailure(err))
+	require.Equal(t, maxTxRetries+1, calls)
+
+	stats := store.TxStats()
+	require.Equal(t, uint64(maxTxRetries), stats.Retries)
+	require.Equal(t, uint64(1), stats.ExhaustedRetries)
+}
+
+func TestExecTxNoRetry(t *testing.T) {
+	store := NewStore(testDB).(*SQLStore)
+
+	// other errors are returned right away
+	calls := 0
+	err := store.execTx(context.Background(), nil, func(q *Queries) error {
+		calls++
+		return ErrInsufficientFunds
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.Equal(t, 1, calls)
+	require.Zero(t, store.TxStats().Retries)
+}
+
+func TestExecTxRetryContextCanceled(t *testing.T) {
+	store := NewStore(testDB).(*SQLStore)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		calls++
+		// the request is canceled while the transaction is running, so we must not try again
+		cancel()
+		return &pq.Error{Code: "40P01"}
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestTxRetryDelay(t *testing.T) {
+	for attempt := 0; attempt < 20; attempt++ {
+		delay := txRetryDelay(attempt)
+		require.GreaterOrEqual(t, delay, time.Duration(0))
+		require.LessOrEqual(t, delay, txRetryMaxDelay)
+		if attempt == 0 {
+			require.LessOrEqual(t, delay, txRetryBaseDelay)
+		}
+	}
+}
+
+func TestExecTxIsolation(t *testing.T) {
+	testCases := []struct {
+		name      string
+		store     *SQLStore
+		opts      *sql.TxOptions
+		isolation string
+	}{
+		{
+			name:      "DatabaseDefault",
+			store:     NewStore(testDB).(*SQLStore),
+			opts:      nil,
+			isolation: "read committed",
+		},
+		{
+			name:      "StoreDefault",
+			store:     NewStore(testDB, WithDefaultIsolation(sql.LevelRepeatableRead)).(*SQLStore),
+			opts:      nil,
+			isolation: "repeatable read",
+		},
+		{
+			// the options of the operation win over the default of the store
+			name:      "Serializable",
+			store:     NewStore(testDB, WithDefaultIsolation(sql.LevelRepeatableRead)).(*SQLStore),
+			opts:      &sql.TxOptions{Isolation: sql.LevelSerializable},
+			isolation: "serializable",
+		},
+		{
+			name:      "ReadCommitted",
+			store:     NewStore(testDB, WithDefaultIsolation(sql.LevelSerializable)).(*SQLStore),
+			opts:      readCommittedTx,
+			isolation: "read committed",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var isolation string
+			err := tc.store.execTx(context.Background(), tc.opts, func(q *Queries) error {
+				return q.db.QueryRowContext(context.Background(), "SHOW transaction_isolation").Scan(&isolation)
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.isolation, isolation)
+		})
+	}
+}
+
+func TestExecTxReadOnly(t *testing.T) {
+	store := NewStore(testDB).(*SQLStore)
+	user := createRandomUser(t)
+
+	// a read only transaction cannot write anything
+	err := store.execTx(context.Background(), readOnlySnapshotTx, func(q *Queries) error {
+		_, err := q.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  0,
+			Currency: utils.GenerateCurrency(),
+		})
+		return err
+	})
+	require.Error(t, err)
+
+	var pqErr *pq.Error
+	require.True(t, errors.As(err, &pqErr))
+	require.Equal(t, "read_only_sql_transaction", string(pqErr.Code.Name()))
+}
+
+func TestParseIsolationLevel(t *testing.T) {
+	testCases := []struct {
+		input string
+		level sql.IsolationLevel
+	}{
+		{"", sql.LevelDefault},
+		{"default", sql.LevelDefault},
+		{"read_committed", sql.LevelReadCommitted},
+		{"READ COMMITTED", sql.LevelReadCommitted},
+		{"repeatable_read", sql.LevelRepeatableRead},
+		{"serializable", sql.LevelSerializable},
+	}
+
+	for _, tc := range testCases {
+		level, err := ParseIsolationLevel(tc.input)
+		require.NoError(t, err)
+		require.Equal(t, tc.level, level)
+	}
+
+	_, err := ParseIsolationLevel("snapshot")
+	require.Error(t, err)
+}
@@ -7,26 +7,49 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/elmas23/simplebank/money"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    from_currency,
+    to_currency,
+    exchange_rate,
+    credited_amount,
+    fee
 ) VALUES (
-             $1, $2, $3
-         ) RETURNING id, from_account_id, to_account_id, amount, created_at
+             $1, $2, $3, $4, $5, $6, $7, $8
+         ) RETURNING id, from_account_id, to_account_id, amount, created_at, from_currency, to_currency, exchange_rate, credited_amount, fee
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID  int64        `json:"from_account_id"`
+	ToAccountID    int64        `json:"to_account_id"`
+	Amount         money.Amount `json:"amount"`
+	FromCurrency   string       `json:"from_currency"`
+	ToCurrency     string       `json:"to_currency"`
+	ExchangeRate   string       `json:"exchange_rate"`
+	CreditedAmount money.Amount `json:"credited_amount"`
+	Fee            money.Amount `json:"fee"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.FromCurrency,
+		arg.ToCurrency,
+		arg.ExchangeRate,
+		arg.CreditedAmount,
+		arg.Fee,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -34,12 +57,17 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.FromCurrency,
+		&i.ToCurrency,
+		&i.ExchangeRate,
+		&i.CreditedAmount,
+		&i.Fee,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, from_currency, to_currency, exchange_rate, credited_amount, fee FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -52,12 +80,17 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.FromCurrency,
+		&i.ToCurrency,
+		&i.ExchangeRate,
+		&i.CreditedAmount,
+		&i.Fee,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, from_currency, to_currency, exchange_rate, credited_amount, fee FROM transfers
 WHERE
         from_account_id = $1 OR
         to_account_id = $2
@@ -93,6 +126,99 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.FromCurrency,
+			&i.ToCurrency,
+			&i.ExchangeRate,
+			&i.CreditedAmount,
+			&i.Fee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersFiltered = `-- name: ListTransfersFiltered :many
+/*
+ This is the keyset version of ListTransfers, see ListAccountsAfter
+ It also filters the transfers, so the client doesn't have to fetch all of them:
+
+        - outgoing and incoming select the direction: the transfers sent by the account, received by the account, or both
+        - since and until select a period, since is included and until is excluded
+        - min_amount and max_amount select a range of amounts, both are included
+          they are in the currency of the account: the amount sent for an outgoing transfer,
+          and the credited amount for an incoming one, since it can be in another currency
+
+ The period and the amounts are optional, we use sqlc.narg so that they are NULL when the client doesn't send them
+ and a NULL filter is always true. lib/pq doesn't reuse the plan of the query, so postgres plans it with the actual
+ values and only uses the conditions and the indexes of the filters that are set
+ */
+
+SELECT id, from_account_id, to_account_id, amount, created_at, from_currency, to_currency, exchange_rate, credited_amount, fee FROM transfers
+WHERE ((from_account_id = $1 AND $2::boolean)
+    OR (to_account_id = $1 AND $3::boolean))
+  AND ($4::timestamptz IS NULL OR created_at >= $4)
+  AND ($5::timestamptz IS NULL OR created_at < $5)
+  AND ($6::bigint IS NULL
+    OR CASE WHEN to_account_id = $1 THEN credited_amount ELSE amount END >= $6)
+  AND ($7::bigint IS NULL
+    OR CASE WHEN to_account_id = $1 THEN credited_amount ELSE amount END <= $7)
+  AND (created_at, id) > ($8::timestamptz, $9::bigint)
+ORDER BY created_at, id
+LIMIT $10
+`
+
+type ListTransfersFilteredParams struct {
+	AccountID      int64         `json:"account_id"`
+	Outgoing       bool          `json:"outgoing"`
+	Incoming       bool          `json:"incoming"`
+	Since          sql.NullTime  `json:"since"`
+	Until          sql.NullTime  `json:"until"`
+	MinAmount      sql.NullInt64 `json:"min_amount"`
+	MaxAmount      sql.NullInt64 `json:"max_amount"`
+	AfterCreatedAt time.Time     `json:"after_created_at"`
+	AfterID        int64         `json:"after_id"`
+	Limit          int32         `json:"limit"`
+}
+
+func (q *Queries) ListTransfersFiltered(ctx context.Context, arg ListTransfersFilteredParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersFiltered,
+		arg.AccountID,
+		arg.Outgoing,
+		arg.Incoming,
+		arg.Since,
+		arg.Until,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.FromCurrency,
+			&i.ToCurrency,
+			&i.ExchangeRate,
+			&i.CreditedAmount,
+			&i.Fee,
 		); err != nil {
 			return nil, err
 		}
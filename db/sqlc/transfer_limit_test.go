@@ -0,0 +1,229 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/money"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// amountLimit and countLimit return a pointer to a limit, since a nil limit means that there is no limit
+func amountLimit(amount money.Amount) *money.Amount {
+	return &amount
+}
+
+func countLimit(count int64) *int64 {
+	return &count
+}
+
+func TestSetAccountTransferLimit(t *testing.T) {
+	account := createRandomAccount(t)
+
+	// an account without any limit has no row
+	_, err := testQueries.GetAccountTransferLimit(context.Background(), account.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	arg := SetAccountTransferLimitParams{
+		AccountID: account.ID,
+		MaxAmount: amountLimit(100),
+	}
+	limit, err := testQueries.SetAccountTransferLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, limit.AccountID)
+	require.Equal(t, arg.MaxAmount, limit.MaxAmount)
+	require.Nil(t, limit.MaxDailyAmount)
+	require.Nil(t, limit.MaxDailyCount)
+	require.NotZero(t, limit.UpdatedAt)
+
+	// setting the limits again replaces all of them, the max amount is removed
+	arg = SetAccountTransferLimitParams{
+		AccountID:      account.ID,
+		MaxDailyAmount: amountLimit(500),
+		MaxDailyCount:  countLimit(3),
+	}
+	limit, err = testQueries.SetAccountTransferLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Nil(t, limit.MaxAmount)
+	require.Equal(t, arg.MaxDailyAmount, limit.MaxDailyAmount)
+	require.Equal(t, arg.MaxDailyCount, limit.MaxDailyCount)
+
+	gotLimit, err := testQueries.GetAccountTransferLimit(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, limit, gotLimit)
+
+	// a negative limit is refused by the database
+	_, err = testQueries.SetAccountTransferLimit(context.Background(), SetAccountTransferLimitParams{
+		AccountID:     account.ID,
+		MaxDailyCount: countLimit(-1),
+	})
+	require.Error(t, err)
+}
+
+func TestSetCurrencyTransferLimit(t *testing.T) {
+	// no account of the tests uses CHF, so its limits cannot make the other tests fail
+	arg := SetCurrencyTransferLimitParams{
+		Currency:       "CHF",
+		MaxAmount:      amountLimit(1000),
+		MaxDailyAmount: amountLimit(5000),
+	}
+	limit, err := testQueries.SetCurrencyTransferLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, "CHF", limit.Currency)
+	require.Equal(t, arg.MaxAmount, limit.MaxAmount)
+	require.Equal(t, arg.MaxDailyAmount, limit.MaxDailyAmount)
+	require.Nil(t, limit.MaxDailyCount)
+
+	gotLimit, err := testQueries.GetCurrencyTransferLimit(context.Background(), "CHF")
+	require.NoError(t, err)
+	require.Equal(t, limit, gotLimit)
+
+	_, err = testQueries.SetCurrencyTransferLimit(context.Background(), SetCurrencyTransferLimitParams{Currency: "CHF"})
+	require.NoError(t, err)
+}
+
+func TestTransferTxLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	transfer := func(amount money.Amount) error {
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        money.New(amount, "USD"),
+		})
+		return err
+	}
+
+	_, err := testQueries.SetAccountTransferLimit(context.Background(), SetAccountTransferLimitParams{
+		AccountID:      account1.ID,
+		MaxAmount:      amountLimit(100),
+		MaxDailyAmount: amountLimit(150),
+		MaxDailyCount:  countLimit(3),
+	})
+	require.NoError(t, err)
+
+	// a single transfer cannot be above the max amount
+	err = transfer(101)
+	var limitErr *LimitExceededError
+	require.True(t, errors.As(err, &limitErr))
+	require.ErrorIs(t, err, ErrLimitExceeded)
+	require.Equal(t, MaxAmountLimit, limitErr.Limit)
+	require.Equal(t, int64(100), limitErr.Max)
+	require.Equal(t, int64(101), limitErr.Requested)
+
+	// the refused transfer is not counted in the daily limits
+	require.NoError(t, transfer(100))
+
+	// 100 were already sent, so 60 more would go above the daily amount
+	err = transfer(60)
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, MaxDailyAmountLimit, limitErr.Limit)
+	require.Equal(t, int64(150), limitErr.Max)
+	require.Equal(t, int64(100), limitErr.Used)
+	require.Equal(t, int64(60), limitErr.Requested)
+
+	require.NoError(t, transfer(25))
+	require.NoError(t, transfer(24))
+
+	// 3 transfers were already sent, the fourth one is refused even if it stays within the daily amount
+	err = transfer(1)
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, MaxDailyCountLimit, limitErr.Limit)
+	require.Equal(t, int64(3), limitErr.Max)
+	require.Equal(t, int64(3), limitErr.Used)
+
+	// nothing was moved by the refused transfers
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-149, updatedAccount1.Balance)
+}
+
+func TestTransferTxLimitsAfterReopen(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 10)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	// the owner empties his account and closes it
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(10, "USD"),
+	})
+	require.NoError(t, err)
+	_, err = store.SetAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account1.ID,
+		Status: utils.ClosedStatus,
+	})
+	require.NoError(t, err)
+
+	// then he opens a new account in the same currency
+	account3, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account1.Owner,
+		Balance:  100,
+		Currency: account1.Currency,
+	})
+	require.NoError(t, err)
+	_, err = testQueries.SetAccountTransferLimit(context.Background(), SetAccountTransferLimitParams{
+		AccountID:     account3.ID,
+		MaxDailyCount: countLimit(1),
+	})
+	require.NoError(t, err)
+
+	// the transfer of the closed account still counts, the daily limits are the ones of the user
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account3.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(10, "USD"),
+	})
+	var limitErr *LimitExceededError
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, MaxDailyCountLimit, limitErr.Limit)
+	require.Equal(t, int64(1), limitErr.Used)
+}
+
+func TestTransferTxCurrencyLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithCurrency(t, 1000, "CHF")
+	account2 := createRandomAccountWithCurrency(t, 0, "CHF")
+	account3 := createRandomAccountWithCurrency(t, 1000, "CHF")
+
+	_, err := testQueries.SetCurrencyTransferLimit(context.Background(), SetCurrencyTransferLimitParams{
+		Currency:  "CHF",
+		MaxAmount: amountLimit(50),
+	})
+	require.NoError(t, err)
+	// the limits of CHF are removed at the end, so the other tests are not limited
+	defer func() {
+		_, err := testQueries.SetCurrencyTransferLimit(context.Background(), SetCurrencyTransferLimitParams{Currency: "CHF"})
+		require.NoError(t, err)
+	}()
+
+	// account 3 can send more than the default of its currency
+	_, err = testQueries.SetAccountTransferLimit(context.Background(), SetAccountTransferLimitParams{
+		AccountID: account3.ID,
+		MaxAmount: amountLimit(200),
+	})
+	require.NoError(t, err)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        money.New(100, "CHF"),
+	}
+	_, err = store.TransferTx(context.Background(), arg)
+	var limitErr *LimitExceededError
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, MaxAmountLimit, limitErr.Limit)
+	require.Equal(t, int64(50), limitErr.Max)
+
+	arg.FromAccountID = account3.ID
+	_, err = store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"github.com/elmas23/simplebank/db/utils"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -10,10 +11,17 @@ import (
 
 func createRandomTransfer(t *testing.T, firstAccount, secondAccount Account) Transfer {
 
+	amount := utils.GenerateAmount()
+	// the transfer is created without TransferTx, so there is no conversion
+	// the rate is formatted like the numeric column returns it
 	arg := CreateTransferParams{
-		FromAccountID: firstAccount.ID,
-		ToAccountID:   secondAccount.ID,
-		Amount:        utils.GenerateAmount(),
+		FromAccountID:  firstAccount.ID,
+		ToAccountID:    secondAccount.ID,
+		Amount:         amount,
+		FromCurrency:   firstAccount.Currency,
+		ToCurrency:     secondAccount.Currency,
+		ExchangeRate:   "1.0000000000",
+		CreditedAmount: amount,
 	}
 	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
 	require.NoError(t, err)
@@ -21,6 +29,10 @@ func createRandomTransfer(t *testing.T, firstAccount, secondAccount Account) Tra
 	require.Equal(t, transfer.FromAccountID, arg.FromAccountID)
 	require.Equal(t, transfer.ToAccountID, arg.ToAccountID)
 	require.Equal(t, transfer.Amount, arg.Amount)
+	require.Equal(t, transfer.FromCurrency, arg.FromCurrency)
+	require.Equal(t, transfer.ToCurrency, arg.ToCurrency)
+	require.Equal(t, transfer.ExchangeRate, arg.ExchangeRate)
+	require.Equal(t, transfer.CreditedAmount, arg.CreditedAmount)
 	require.NotZero(t, transfer.ID)
 	require.NotZero(t, transfer.CreatedAt)
 
@@ -72,3 +84,131 @@ func TestListTransfers(t *testing.T) {
 		require.NotEmpty(t, transfer)
 	}
 }
+
+func TestListTransfersFiltered(t *testing.T) {
+	firstAccount := createRandomAccount(t)
+	secondAccount := createRandomAccount(t)
+
+	// the first account sends 5 transfers and receives 5 transfers
+	for i := 0; i < 5; i++ {
+		createRandomTransfer(t, firstAccount, secondAccount)
+		createRandomTransfer(t, secondAccount, firstAccount)
+	}
+
+	// without filters, we get all the transfers page by page
+	page1, err := testQueries.ListTransfersFiltered(context.Background(), ListTransfersFilteredParams{
+		AccountID: firstAccount.ID,
+		Outgoing:  true,
+		Incoming:  true,
+		Limit:     6,
+	})
+	require.NoError(t, err)
+	require.Len(t, page1, 6)
+
+	last := page1[len(page1)-1]
+	page2, err := testQueries.ListTransfersFiltered(context.Background(), ListTransfersFilteredParams{
+		AccountID:      firstAccount.ID,
+		Outgoing:       true,
+		Incoming:       true,
+		AfterCreatedAt: last.CreatedAt,
+		AfterID:        last.ID,
+		Limit:          6,
+	})
+	require.NoError(t, err)
+	require.Len(t, page2, 4)
+
+	for _, transfer := range append(page1, page2...) {
+		require.True(t, transfer.FromAccountID == firstAccount.ID || transfer.ToAccountID == firstAccount.ID)
+	}
+
+	// only the outgoing transfers
+	outgoing, err := testQueries.ListTransfersFiltered(context.Background(), ListTransfersFilteredParams{
+		AccountID: firstAccount.ID,
+		Outgoing:  true,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, outgoing, 5)
+	for _, transfer := range outgoing {
+		require.Equal(t, firstAccount.ID, transfer.FromAccountID)
+	}
+
+	// a range of amounts, both ends are included
+	minAmount := int64(outgoing[0].Amount)
+	incoming, err := testQueries.ListTransfersFiltered(context.Background(), ListTransfersFilteredParams{
+		AccountID: firstAccount.ID,
+		Incoming:  true,
+		MinAmount: sql.NullInt64{Int64: minAmount, Valid: true},
+		MaxAmount: sql.NullInt64{Int64: minAmount + 100, Valid: true},
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	for _, transfer := range incoming {
+		require.Equal(t, firstAccount.ID, transfer.ToAccountID)
+		require.GreaterOrEqual(t, int64(transfer.CreditedAmount), minAmount)
+		require.LessOrEqual(t, int64(transfer.CreditedAmount), minAmount+100)
+	}
+
+	// a period in the future has no transfer
+	future, err := testQueries.ListTransfersFiltered(context.Background(), ListTransfersFilteredParams{
+		AccountID: firstAccount.ID,
+		Outgoing:  true,
+		Incoming:  true,
+		Since:     sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, future)
+
+	// and a period that ends before the first transfer neither
+	past, err := testQueries.ListTransfersFiltered(context.Background(), ListTransfersFilteredParams{
+		AccountID: firstAccount.ID,
+		Outgoing:  true,
+		Incoming:  true,
+		Until:     sql.NullTime{Time: page1[0].CreatedAt, Valid: true},
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, past)
+
+	// the amounts are in the currency of the listed account, even when the transfer is converted
+	usdAccount := createRandomAccountWithCurrency(t, 0, "USD")
+	eurAccount := createRandomAccountWithCurrency(t, 0, "EUR")
+
+	// 100 USD are sent, and the receiver is credited 90 EUR
+	transfer, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID:  usdAccount.ID,
+		ToAccountID:    eurAccount.ID,
+		Amount:         100,
+		FromCurrency:   "USD",
+		ToCurrency:     "EUR",
+		ExchangeRate:   "0.9000000000",
+		CreditedAmount: 90,
+	})
+	require.NoError(t, err)
+
+	listByAmount := func(account Account, amount int64) []Transfer {
+		transfers, err := testQueries.ListTransfersFiltered(context.Background(), ListTransfersFilteredParams{
+			AccountID: account.ID,
+			Outgoing:  true,
+			Incoming:  true,
+			MinAmount: sql.NullInt64{Int64: amount, Valid: true},
+			MaxAmount: sql.NullInt64{Int64: amount, Valid: true},
+			Limit:     10,
+		})
+		require.NoError(t, err)
+		return transfers
+	}
+
+	// the sender filters on the amount in USD
+	transfers := listByAmount(usdAccount, 100)
+	require.Len(t, transfers, 1)
+	require.Equal(t, transfer.ID, transfers[0].ID)
+	require.Empty(t, listByAmount(usdAccount, 90))
+
+	// and the receiver on the credited amount in EUR
+	transfers = listByAmount(eurAccount, 90)
+	require.Len(t, transfers, 1)
+	require.Equal(t, transfer.ID, transfers[0].ID)
+	require.Empty(t, listByAmount(eurAccount, 100))
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"github.com/elmas23/simplebank/db/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// createRandomUser creates a user that other tests can use
+// every account must belong to an existing user, so this is needed to create accounts
+func createRandomUser(t *testing.T) User {
+	hashedPassword, err := utils.HashPassword(utils.GenerateRandomString(6))
+	require.NoError(t, err)
+
+	arg := CreateUserParams{
+		Username:       utils.GenerateOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       utils.GenerateOwner(),
+		Email:          utils.GenerateEmail(),
+		Role:           utils.DepositorRole,
+	}
+
+	user, err := testQueries.CreateUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, user)
+
+	require.Equal(t, arg.Username, user.Username)
+	require.Equal(t, arg.HashedPassword, user.HashedPassword)
+	require.Equal(t, arg.FullName, user.FullName)
+	require.Equal(t, arg.Email, user.Email)
+	require.Equal(t, arg.Role, user.Role)
+
+	// the password has never been changed, so it is set to the default zero timestamp
+	require.True(t, user.PasswordChangedAt.IsZero())
+	require.NotZero(t, user.CreatedAt)
+
+	return user
+}
+
+func TestCreateUser(t *testing.T) {
+	createRandomUser(t)
+}
+
+func TestGetUser(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2, err := testQueries.GetUser(context.Background(), user1.Username)
+	require.NoError(t, err)
+	require.NotEmpty(t, user2)
+
+	require.Equal(t, user1.Username, user2.Username)
+	require.Equal(t, user1.HashedPassword, user2.HashedPassword)
+	require.Equal(t, user1.FullName, user2.FullName)
+	require.Equal(t, user1.Email, user2.Email)
+	require.Equal(t, user1.Role, user2.Role)
+	require.WithinDuration(t, user1.PasswordChangedAt, user2.PasswordChangedAt, time.Second)
+	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
+}
@@ -0,0 +1,11 @@
+package utils
+
+// These are the statuses that an account can have
+// An active account can send and receive money
+// A frozen account cannot send or receive money until a banker unfreezes it
+// A closed account can never be used again, it is kept because the ledger references it
+const (
+	ActiveStatus = "active"
+	FrozenStatus = "frozen"
+	ClosedStatus = "closed"
+)
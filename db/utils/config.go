@@ -1,14 +1,32 @@
 package utils
 
-import "github.com/spf13/viper"
+import (
+	"github.com/spf13/viper"
+	"time"
+)
 
 // Config stored all configuration of the application
 // The values are read by viper from a config file or environment variable
 // we add mapstructure to allow for marshalling to be done when viper read this config file
 type Config struct {
-	DBDriver      string `mapstructure:"DB_DRIVER"`
-	DBSource      string `mapstructure:"DB_SOURCE"`
-	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+	DBDriver             string        `mapstructure:"DB_DRIVER"`
+	DBSource             string        `mapstructure:"DB_SOURCE"`
+	DBIsolationLevel     string        `mapstructure:"DB_ISOLATION_LEVEL"` // used by the transactions that don't declare their own, like "read_committed"
+	FXRatesFile          string        `mapstructure:"FX_RATES_FILE"`      // the exchange rates of the transfers between currencies, none if empty
+	FeeRulesFile         string        `mapstructure:"FEE_RULES_FILE"`     // the fee schedule of the transfers, they are free if empty
+	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
+	SupportedCurrencies  []string      `mapstructure:"SUPPORTED_CURRENCIES"`   // ISO 4217 codes separated by commas, like "USD,EUR"
+	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`    // must be exactly 32 characters for PASETO
+	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`  // viper parses values like 15m into a duration
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"` // a refresh token lives much longer than an access token
+	// how long a transfer above the approval threshold can wait for a banker, the store has a default if it is not set
+	TransferApprovalExpiry time.Duration `mapstructure:"TRANSFER_APPROVAL_EXPIRY"`
+	// how long a hold reserves its money before it expires, the store has a default if it is not set
+	HoldExpiry time.Duration `mapstructure:"HOLD_EXPIRY"`
+	// how often the scheduler looks for due standing orders and expired holds, the scheduler doesn't run if it is not set
+	StandingOrderPollInterval time.Duration `mapstructure:"STANDING_ORDER_POLL_INTERVAL"`
+	StandingOrderMaxAttempts  int32         `mapstructure:"STANDING_ORDER_MAX_ATTEMPTS"` // the attempts of a run before it is skipped
+	StandingOrderRetryDelay   time.Duration `mapstructure:"STANDING_ORDER_RETRY_DELAY"`  // the wait after the first failed attempt, it doubles every time
 }
 
 // LoadConfig reads configuration from file or environment variables
@@ -0,0 +1,10 @@
+package utils
+
+// These are the statuses that a hold can have
+// Only an active hold reserves money and can be captured, the other statuses are final
+const (
+	ActiveHoldStatus   = "active"
+	CapturedHoldStatus = "captured"
+	ReleasedHoldStatus = "released"
+	ExpiredHoldStatus  = "expired"
+)
@@ -0,0 +1,14 @@
+package utils
+
+// These are the kinds of the journal entries, that is the business events recorded in the ledger
+// A transfer moves money from one account to another, see TransferTx
+// A deposit brings money from outside the bank into an account, and a withdrawal takes it out, see DepositTx and WithdrawTx
+// An adjustment corrects a balance by hand, its other side is usually the suspense account
+// A migration groups the entries that existed before the journal, they were not recorded by a transfer
+const (
+	TransferEntryKind   = "transfer"
+	DepositEntryKind    = "deposit"
+	WithdrawalEntryKind = "withdrawal"
+	AdjustmentEntryKind = "adjustment"
+	MigrationEntryKind  = "migration"
+)
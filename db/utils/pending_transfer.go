@@ -0,0 +1,24 @@
+package utils
+
+// These are the statuses that a pending transfer can have, see the pending transfers in TransferTx
+// A pending transfer waits for a banker, who approves or rejects it
+// An approved transfer is executed right away, it stays approved if it cannot be executed yet
+// A rejected, executed or expired transfer can no longer change
+const (
+	PendingTransferStatus  = "pending"
+	ApprovedTransferStatus = "approved"
+	RejectedTransferStatus = "rejected"
+	ExecutedTransferStatus = "executed"
+	ExpiredTransferStatus  = "expired"
+)
+
+// These are the actions recorded in the audit trail of the pending transfers
+// Each one is saved with the user who did it and the status of the pending transfer after it
+const (
+	RequestedTransferAction       = "requested"
+	ApprovedTransferAction        = "approved"
+	RejectedTransferAction        = "rejected"
+	ExecutedTransferAction        = "executed"
+	ExecutionFailedTransferAction = "execution_failed"
+	ExpiredTransferAction         = "expired"
+)
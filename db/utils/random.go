@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"fmt"
+	"github.com/elmas23/simplebank/money"
 	"math/rand"
 	"strings"
 	"time"
@@ -42,8 +44,8 @@ func GenerateOwner() string {
 }
 
 // GenerateBalance generates a random amount for the balance
-func GenerateBalance() int64 {
-	return GenerateRandomInt(0, 1000)
+func GenerateBalance() money.Amount {
+	return money.Amount(GenerateRandomInt(0, 1000))
 }
 
 // GenerateCurrency generates a random currency code
@@ -54,6 +56,11 @@ func GenerateCurrency() string {
 }
 
 // GenerateAmount generates a random amount for the amount field
-func GenerateAmount() int64 {
-	return GenerateRandomInt(0, 1000)
+func GenerateAmount() money.Amount {
+	return money.Amount(GenerateRandomInt(0, 1000))
+}
+
+// GenerateEmail generates a random email
+func GenerateEmail() string {
+	return fmt.Sprintf("%s@email.com", GenerateRandomString(6))
 }
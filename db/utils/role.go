@@ -0,0 +1,11 @@
+package utils
+
+// These are the roles that a user can have
+// A depositor is a normal customer of the bank
+// A banker is an employee of the bank who is allowed to use the admin routes
+// A system user owns the system accounts of the bank, it is created by the migrations and cannot log in
+const (
+	DepositorRole = "depositor"
+	BankerRole    = "banker"
+	SystemRole    = "system"
+)
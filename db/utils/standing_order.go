@@ -0,0 +1,21 @@
+package utils
+
+// These are the statuses that a standing order can have
+// An active standing order runs on its schedule, a paused one waits until it is active again and skips the runs it missed
+// A cancelled or completed standing order can no longer change
+const (
+	ActiveStandingOrderStatus    = "active"
+	PausedStandingOrderStatus    = "paused"
+	CancelledStandingOrderStatus = "cancelled"
+	CompletedStandingOrderStatus = "completed"
+)
+
+// These are the outcomes recorded for each attempt of a run of a standing order
+// A run that was missed while the standing order was paused is recorded as skipped, it has no attempt
+const (
+	SucceededRunOutcome       = "succeeded"
+	PendingApprovalRunOutcome = "pending_approval"
+	RetryingRunOutcome        = "retrying"
+	FailedRunOutcome          = "failed"
+	SkippedRunOutcome         = "skipped"
+)
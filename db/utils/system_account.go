@@ -0,0 +1,14 @@
+package utils
+
+// These are the owners of the system accounts, that is the accounts of the bank itself
+// A system account takes the other side of the postings that don't move money between two customers
+// The fees account receives the fees paid by the customers
+// The FX account buys the currency of the sender and sells the currency of the receiver in a foreign exchange
+// The settlement account stands for the money outside the bank, it is the other side of the deposits and withdrawals
+// The suspense account holds the money that cannot be explained yet, until someone investigates it
+const (
+	FeesAccountOwner       = "system_fees"
+	FXAccountOwner         = "system_fx"
+	SettlementAccountOwner = "system_settlement"
+	SuspenseAccountOwner   = "system_suspense"
+)
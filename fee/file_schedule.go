@@ -0,0 +1,30 @@
+package fee
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ruleFile is the content of a fee file, for example:
+//
+//	{"rules": [{"currency": "USD", "transfer_type": "cross_currency", "flat": 50, "percent": "1"}]}
+type ruleFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+// NewFileSchedule creates a StaticSchedule with the rules of a JSON file
+// The file is only read once, the server must be restarted to use new rules
+func NewFileSchedule(path string) (Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read fee file: %w", err)
+	}
+
+	var file ruleFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse fee file: %w", err)
+	}
+
+	return NewStaticSchedule(file.Rules)
+}
@@ -0,0 +1,35 @@
+package fee
+
+import (
+	"context"
+	"errors"
+	"github.com/elmas23/simplebank/money"
+)
+
+// Schedule is an interface for getting the fee of a transfer
+// Like fx.RateProvider, the store only depends on this interface, so the rules can come from
+// a file, a database table or another service without changing the transfers
+type Schedule interface {
+	// Fee returns the fee paid by the sender for a transfer of amount, in the currency of the amount
+	// The fee is zero when no rule applies to the transfer
+	Fee(ctx context.Context, transferType string, amount money.Money) (money.Money, error)
+}
+
+// These are the types of transfers, a rule can apply to one of them or to both
+// A transfer between two currencies usually costs more, since the bank has to exchange the money
+const (
+	SameCurrencyTransfer  = "same_currency"
+	CrossCurrencyTransfer = "cross_currency"
+)
+
+// TransferType returns the type of a transfer from the currency of the sender to the currency of the receiver
+func TransferType(fromCurrency string, toCurrency string) string {
+	if fromCurrency == toCurrency {
+		return SameCurrencyTransfer
+	}
+	return CrossCurrencyTransfer
+}
+
+var (
+	ErrInvalidRule = errors.New("invalid fee rule")
+)
@@ -0,0 +1,139 @@
+package fee
+
+import (
+	"context"
+	"fmt"
+	"github.com/elmas23/simplebank/money"
+	"math"
+	"math/big"
+	"sort"
+)
+
+/*
+How is the fee of a transfer computed ?
+
+		A rule applies to the transfers in one currency, and optionally to one type of transfer.
+		Its fee is a flat amount plus a percentage of the amount of the transfer, either can be zero:
+
+				- flat:        {"currency": "USD", "flat": 25}                       always 0.25 USD
+				- percentage:  {"currency": "USD", "percent": "1.5"}                 1.5% of the amount
+				- both:        {"currency": "USD", "flat": 25, "percent": "1.5"}     0.25 USD + 1.5%
+
+		The rules are tiered by amount: a rule applies from its min_amount, until the min_amount of the next rule.
+		So the fee of a transfer is given by the rule with the highest min_amount that is not above its amount.
+		A rule for the type of the transfer is preferred over a rule for every type with the same min_amount.
+
+		The percentage is rounded down to the minor unit of the currency, so the customer never pays more
+		than what the schedule says. The amounts are in minor units, like the amounts of the transfers.
+*/
+
+// Rule is one line of a fee schedule
+// The percent is a string, so it is not rounded by a float when the rules are read
+type Rule struct {
+	Currency     string       `json:"currency"`
+	TransferType string       `json:"transfer_type"` // one of the transfer types, or empty for every type
+	MinAmount    money.Amount `json:"min_amount"`    // the tier starts at this amount, included
+	Flat         money.Amount `json:"flat"`
+	Percent      string       `json:"percent"` // like "1.5" for 1.5%, or empty for none
+}
+
+// rule is a Rule with its percentage parsed
+type rule struct {
+	Rule
+	percent *big.Rat
+}
+
+// StaticSchedule is a fee schedule with a fixed list of rules
+// It doesn't need any network access, so it is used for the tests and to run the server offline
+type StaticSchedule struct {
+	rules map[string][]rule // the rules of each currency, sorted by min_amount
+}
+
+// NewStaticSchedule creates a new StaticSchedule
+func NewStaticSchedule(rules []Rule) (Schedule, error) {
+	schedule := &StaticSchedule{
+		rules: make(map[string][]rule),
+	}
+
+	for _, value := range rules {
+		parsed, err := parseRule(value)
+		if err != nil {
+			return nil, err
+		}
+		schedule.rules[value.Currency] = append(schedule.rules[value.Currency], parsed)
+	}
+
+	for _, currencyRules := range schedule.rules {
+		sort.SliceStable(currencyRules, func(i, j int) bool {
+			return currencyRules[i].MinAmount < currencyRules[j].MinAmount
+		})
+	}
+	return schedule, nil
+}
+
+// parseRule checks a rule and parses its percentage
+func parseRule(value Rule) (rule, error) {
+	if len(value.Currency) == 0 {
+		return rule{}, fmt.Errorf("%w: the currency is missing", ErrInvalidRule)
+	}
+	switch value.TransferType {
+	case "", SameCurrencyTransfer, CrossCurrencyTransfer:
+	default:
+		return rule{}, fmt.Errorf("%w: unknown transfer type %q", ErrInvalidRule, value.TransferType)
+	}
+	if value.MinAmount < 0 || value.Flat < 0 {
+		return rule{}, fmt.Errorf("%w: the amounts of a %s rule cannot be negative", ErrInvalidRule, value.Currency)
+	}
+
+	parsed := rule{Rule: value, percent: new(big.Rat)}
+	if len(value.Percent) > 0 {
+		percent, ok := new(big.Rat).SetString(value.Percent)
+		if !ok || percent.Sign() < 0 {
+			return rule{}, fmt.Errorf("%w: the percent of a %s rule is %q", ErrInvalidRule, value.Currency, value.Percent)
+		}
+		parsed.percent = percent
+	}
+	return parsed, nil
+}
+
+// Fee returns the fee paid by the sender for a transfer of amount, in the currency of the amount
+func (schedule *StaticSchedule) Fee(ctx context.Context, transferType string, amount money.Money) (money.Money, error) {
+	fee := money.New(0, amount.Currency)
+
+	applied, ok := schedule.find(transferType, amount)
+	if !ok {
+		return fee, nil
+	}
+
+	// amount * percent / 100, rounded down since both are positive
+	numerator := new(big.Int).Mul(big.NewInt(int64(amount.Amount)), applied.percent.Num())
+	denominator := new(big.Int).Mul(applied.percent.Denom(), big.NewInt(100))
+	percentage := new(big.Int).Quo(numerator, denominator)
+	if !percentage.IsInt64() || percentage.Int64() > math.MaxInt64-int64(applied.Flat) {
+		return fee, fmt.Errorf("%w: fee of %s", money.ErrOverflow, amount)
+	}
+
+	fee.Amount = applied.Flat + money.Amount(percentage.Int64())
+	return fee, nil
+}
+
+// find returns the rule that applies to a transfer, false if there is none
+func (schedule *StaticSchedule) find(transferType string, amount money.Money) (rule, bool) {
+	var found rule
+	ok := false
+	for _, candidate := range schedule.rules[amount.Currency] {
+		// the rules are sorted by min_amount, so the following ones don't apply either
+		if candidate.MinAmount > amount.Amount {
+			break
+		}
+		if len(candidate.TransferType) > 0 && candidate.TransferType != transferType {
+			continue
+		}
+		// a rule for every type doesn't replace a rule for this type in the same tier
+		if ok && candidate.MinAmount == found.MinAmount && len(candidate.TransferType) == 0 && len(found.TransferType) > 0 {
+			continue
+		}
+		found, ok = candidate, true
+	}
+	return found, ok
+}
@@ -0,0 +1,97 @@
+package fee
+
+import (
+	"context"
+	"github.com/elmas23/simplebank/money"
+	"github.com/stretchr/testify/require"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStaticSchedule(t *testing.T) {
+	schedule, err := NewStaticSchedule([]Rule{
+		{Currency: "USD", Flat: 25},
+		{Currency: "USD", MinAmount: 10000, Percent: "0.5"},
+		{Currency: "USD", MinAmount: 10000, TransferType: CrossCurrencyTransfer, Flat: 100, Percent: "1.5"},
+		{Currency: "EUR", TransferType: CrossCurrencyTransfer, Percent: "2"},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		transferType string
+		amount       money.Money
+		fee          money.Money
+	}{
+		{"Flat", SameCurrencyTransfer, money.New(5000, "USD"), money.New(25, "USD")},
+		{"FlatForEveryType", CrossCurrencyTransfer, money.New(5000, "USD"), money.New(25, "USD")},
+		{"TierStartIncluded", SameCurrencyTransfer, money.New(10000, "USD"), money.New(50, "USD")},
+		{"PercentRoundedDown", SameCurrencyTransfer, money.New(10199, "USD"), money.New(50, "USD")},
+		{"TypePreferred", CrossCurrencyTransfer, money.New(20000, "USD"), money.New(400, "USD")},
+		{"OtherType", SameCurrencyTransfer, money.New(5000, "EUR"), money.New(0, "EUR")},
+		{"Percent", CrossCurrencyTransfer, money.New(5000, "EUR"), money.New(100, "EUR")},
+		{"NoRule", SameCurrencyTransfer, money.New(5000, "CAD"), money.New(0, "CAD")},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			fee, err := schedule.Fee(context.Background(), tc.transferType, tc.amount)
+			require.NoError(t, err)
+			require.Equal(t, tc.fee, fee)
+		})
+	}
+}
+
+func TestStaticScheduleOverflow(t *testing.T) {
+	schedule, err := NewStaticSchedule([]Rule{{Currency: "USD", Flat: 1, Percent: "100"}})
+	require.NoError(t, err)
+
+	_, err = schedule.Fee(context.Background(), SameCurrencyTransfer, money.New(math.MaxInt64, "USD"))
+	require.ErrorIs(t, err, money.ErrOverflow)
+}
+
+func TestStaticScheduleInvalidRule(t *testing.T) {
+	invalidRules := []Rule{
+		{Flat: 25},
+		{Currency: "USD", TransferType: "wire"},
+		{Currency: "USD", Flat: -1},
+		{Currency: "USD", MinAmount: -1},
+		{Currency: "USD", Percent: "-1"},
+		{Currency: "USD", Percent: "one"},
+	}
+
+	for _, rule := range invalidRules {
+		_, err := NewStaticSchedule([]Rule{rule})
+		require.ErrorIs(t, err, ErrInvalidRule)
+	}
+}
+
+func TestTransferType(t *testing.T) {
+	require.Equal(t, SameCurrencyTransfer, TransferType("USD", "USD"))
+	require.Equal(t, CrossCurrencyTransfer, TransferType("USD", "EUR"))
+}
+
+func TestFileSchedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fees.json")
+	err := os.WriteFile(path, []byte(`{"rules": [{"currency": "USD", "flat": 25, "percent": "1"}]}`), 0600)
+	require.NoError(t, err)
+
+	schedule, err := NewFileSchedule(path)
+	require.NoError(t, err)
+
+	fee, err := schedule.Fee(context.Background(), SameCurrencyTransfer, money.New(1000, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, money.New(35, "USD"), fee)
+
+	_, err = NewFileSchedule(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+
+	err = os.WriteFile(path, []byte(`{"rules": [{"flat": 25}]}`), 0600)
+	require.NoError(t, err)
+	_, err = NewFileSchedule(path)
+	require.ErrorIs(t, err, ErrInvalidRule)
+}
@@ -0,0 +1,36 @@
+package fx
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// rateFile is the content of a rate file, for example:
+//
+//	{"base": "USD", "rates": {"EUR": "0.92", "CAD": "1.36"}}
+//
+// The rates are strings, so they are not rounded by a float when the file is read
+type rateFile struct {
+	Base  string            `json:"base"`
+	Rates map[string]string `json:"rates"`
+}
+
+// NewFileProvider creates a StaticProvider with the rates of a JSON file
+// The file is only read once, the server must be restarted to use new rates
+func NewFileProvider(path string) (RateProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read rate file: %w", err)
+	}
+
+	var file rateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse rate file: %w", err)
+	}
+	if len(file.Base) == 0 {
+		return nil, fmt.Errorf("rate file %s has no base currency", path)
+	}
+
+	return NewStaticProvider(file.Base, file.Rates)
+}
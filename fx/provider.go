@@ -0,0 +1,22 @@
+package fx
+
+import (
+	"context"
+	"errors"
+)
+
+// RateProvider is an interface for getting exchange rates
+// The store only depends on this interface, so the rates can come from a file, a database
+// or the API of a bank without changing the transfers
+type RateProvider interface {
+	// Rate returns the rate to convert an amount in the currency from into the currency to
+	// ErrRateNotFound is returned when the provider doesn't know one of the two currencies
+	Rate(ctx context.Context, from string, to string) (Rate, error)
+}
+
+var (
+	ErrRateNotFound   = errors.New("exchange rate not found")
+	ErrInvalidRate    = errors.New("exchange rate must be a positive number")
+	ErrAmountTooSmall = errors.New("converted amount is zero")
+	ErrAmountTooLarge = errors.New("converted amount is too large")
+)
@@ -0,0 +1,95 @@
+package fx
+
+import (
+	"fmt"
+	"github.com/elmas23/simplebank/currency"
+	"github.com/elmas23/simplebank/money"
+	"math/big"
+)
+
+/*
+How do we convert an amount ?
+
+		The amounts are integers in the minor unit of their currency, like cents.
+		A rate is never stored as a float, since 0.1 cannot even be represented exactly.
+		Instead it is a fraction (big.Rat) rounded to RateScale decimals, which is also how it is saved
+		in the numeric column of the transfers. So the converted amount can always be computed again
+		from what is stored, which is what an auditor will do.
+
+		The rate is between the major units of the currencies, like 150 yens for 1 dollar, but the amounts
+		are in minor units, like cents. So the amount is also scaled by the difference of their minor units:
+		1000 cents (10 dollars) at 150 is 1500 yens.
+
+		The converted amount is rounded down: the bank never credits more than what was debited.
+*/
+
+// RateScale is the number of decimals kept in a rate
+const RateScale = 10
+
+// Rate is the exchange rate from one currency to another
+type Rate struct {
+	From  string
+	To    string
+	value *big.Rat
+}
+
+// NewRate creates a rate from its decimal representation, like "0.92"
+func NewRate(from string, to string, value string) (Rate, error) {
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return Rate{}, fmt.Errorf("%w: %q", ErrInvalidRate, value)
+	}
+	return newRate(from, to, rat)
+}
+
+// IdentityRate is the rate of a currency to itself
+func IdentityRate(currency string) Rate {
+	return Rate{From: currency, To: currency, value: big.NewRat(1, 1)}
+}
+
+// newRate rounds the value to RateScale decimals and checks that it is still positive
+func newRate(from string, to string, value *big.Rat) (Rate, error) {
+	rounded, ok := new(big.Rat).SetString(value.FloatString(RateScale))
+	if !ok || rounded.Sign() <= 0 {
+		return Rate{}, fmt.Errorf("%w: %s to %s", ErrInvalidRate, from, to)
+	}
+	return Rate{From: from, To: to, value: rounded}, nil
+}
+
+// String returns the rate with RateScale decimals, it is the value saved with the transfers
+func (rate Rate) String() string {
+	if rate.value == nil {
+		return ""
+	}
+	return rate.value.FloatString(RateScale)
+}
+
+// Convert returns the amount converted into the currency To, the amount must be in the currency From
+// ErrAmountTooSmall is returned when a positive amount is worth nothing once converted,
+// since the receiver would get nothing for the money of the sender
+func (rate Rate) Convert(amount money.Money) (money.Money, error) {
+	if rate.value == nil {
+		return money.Money{}, fmt.Errorf("%w: %s to %s", ErrInvalidRate, rate.From, rate.To)
+	}
+	if amount.Currency != rate.From {
+		return money.Money{}, fmt.Errorf("%w: %s is not %s", money.ErrCurrencyMismatch, amount.Currency, rate.From)
+	}
+
+	numerator := new(big.Int).Mul(big.NewInt(int64(amount.Amount)), rate.value.Num())
+	numerator.Mul(numerator, pow10(currency.MinorUnits(rate.To)))
+	denominator := new(big.Int).Mul(rate.value.Denom(), pow10(currency.MinorUnits(rate.From)))
+	converted := numerator.Quo(numerator, denominator) // Quo rounds toward zero
+
+	if !converted.IsInt64() {
+		return money.Money{}, ErrAmountTooLarge
+	}
+	if amount.Amount > 0 && converted.Sign() == 0 {
+		return money.Money{}, ErrAmountTooSmall
+	}
+	return money.New(money.Amount(converted.Int64()), rate.To), nil
+}
+
+// pow10 returns 10^n
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,71 @@
+package fx
+
+import (
+	"github.com/elmas23/simplebank/money"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewRate(t *testing.T) {
+	rate, err := NewRate("USD", "EUR", "0.92")
+	require.NoError(t, err)
+	require.Equal(t, "USD", rate.From)
+	require.Equal(t, "EUR", rate.To)
+	require.Equal(t, "0.9200000000", rate.String())
+
+	// the rate is rounded to RateScale decimals
+	rate, err = NewRate("USD", "EUR", "0.123456789012")
+	require.NoError(t, err)
+	require.Equal(t, "0.1234567890", rate.String())
+
+	for _, value := range []string{"", "abc", "0", "-1", "0.00000000001"} {
+		_, err = NewRate("USD", "EUR", value)
+		require.ErrorIs(t, err, ErrInvalidRate)
+	}
+}
+
+func TestRateConvert(t *testing.T) {
+	rate, err := NewRate("USD", "EUR", "0.92")
+	require.NoError(t, err)
+
+	amount, err := rate.Convert(money.New(1000, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, money.New(920, "EUR"), amount)
+
+	// the converted amount is rounded down
+	amount, err = rate.Convert(money.New(99, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, money.New(91, "EUR"), amount)
+
+	_, err = rate.Convert(money.New(1, "USD"))
+	require.ErrorIs(t, err, ErrAmountTooSmall)
+
+	// the amount must be in the currency of the rate
+	_, err = rate.Convert(money.New(1000, "EUR"))
+	require.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	// the yen has no minor unit, so 1000 cents at 150 are 1500 yens
+	rate, err = NewRate("USD", "JPY", "150")
+	require.NoError(t, err)
+	amount, err = rate.Convert(money.New(1000, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, money.New(1500, "JPY"), amount)
+
+	rate, err = NewRate("JPY", "USD", "0.0066")
+	require.NoError(t, err)
+	amount, err = rate.Convert(money.New(1500, "JPY"))
+	require.NoError(t, err)
+	require.Equal(t, money.New(990, "USD"), amount)
+
+	rate, err = NewRate("USD", "KWD", "1000")
+	require.NoError(t, err)
+	_, err = rate.Convert(money.New(1<<62, "USD"))
+	require.ErrorIs(t, err, ErrAmountTooLarge)
+
+	amount, err = IdentityRate("USD").Convert(money.New(1234, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, money.New(1234, "USD"), amount)
+
+	_, err = Rate{}.Convert(money.New(10, "USD"))
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
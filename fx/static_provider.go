@@ -0,0 +1,53 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// StaticProvider is a rate provider with a fixed list of rates
+// It doesn't need any network access, so it is used for the tests and to run the server offline
+// Every rate is given against a single base currency, the rate between two other currencies
+// is computed from them, so the rates are always consistent with each other
+type StaticProvider struct {
+	base  string
+	rates map[string]*big.Rat
+}
+
+// NewStaticProvider creates a new StaticProvider
+// rates holds how much of each currency is worth one unit of the base currency, like {"EUR": "0.92"}
+func NewStaticProvider(base string, rates map[string]string) (RateProvider, error) {
+	provider := &StaticProvider{
+		base:  base,
+		rates: map[string]*big.Rat{base: big.NewRat(1, 1)},
+	}
+
+	for currency, value := range rates {
+		rat, ok := new(big.Rat).SetString(value)
+		if !ok || rat.Sign() <= 0 {
+			return nil, fmt.Errorf("%w: %s is %q", ErrInvalidRate, currency, value)
+		}
+		provider.rates[currency] = rat
+	}
+	return provider, nil
+}
+
+// Rate returns the rate to convert an amount in the currency from into the currency to
+func (provider *StaticProvider) Rate(ctx context.Context, from string, to string) (Rate, error) {
+	if from == to {
+		return IdentityRate(from), nil
+	}
+
+	fromRate, ok := provider.rates[from]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w: %s to %s", ErrRateNotFound, from, to)
+	}
+	toRate, ok := provider.rates[to]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w: %s to %s", ErrRateNotFound, from, to)
+	}
+
+	// 1 from = (1 / fromRate) base = (toRate / fromRate) to
+	return newRate(from, to, new(big.Rat).Quo(toRate, fromRate))
+}
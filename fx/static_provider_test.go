@@ -0,0 +1,59 @@
+package fx
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStaticProvider(t *testing.T) {
+	provider, err := NewStaticProvider("USD", map[string]string{"EUR": "0.8", "CAD": "1.25"})
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, "0.8000000000", rate.String())
+
+	rate, err = provider.Rate(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+	require.Equal(t, "1.2500000000", rate.String())
+
+	// the cross rate is computed with the base currency
+	rate, err = provider.Rate(context.Background(), "EUR", "CAD")
+	require.NoError(t, err)
+	require.Equal(t, "1.5625000000", rate.String())
+
+	// a currency always has a rate to itself, even if the provider doesn't know it
+	rate, err = provider.Rate(context.Background(), "GBP", "GBP")
+	require.NoError(t, err)
+	require.Equal(t, "1.0000000000", rate.String())
+
+	_, err = provider.Rate(context.Background(), "USD", "GBP")
+	require.ErrorIs(t, err, ErrRateNotFound)
+
+	_, err = NewStaticProvider("USD", map[string]string{"EUR": "-0.8"})
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	err := os.WriteFile(path, []byte(`{"base": "USD", "rates": {"EUR": "0.92"}}`), 0600)
+	require.NoError(t, err)
+
+	provider, err := NewFileProvider(path)
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, "0.9200000000", rate.String())
+
+	_, err = NewFileProvider(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+
+	err = os.WriteFile(path, []byte(`{"rates": {"EUR": "0.92"}}`), 0600)
+	require.NoError(t, err)
+	_, err = NewFileProvider(path)
+	require.Error(t, err)
+}
@@ -3,12 +3,18 @@ package main
 // This will be the entry point for our server
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"log"
+	"os"
 
 	"github.com/elmas23/simplebank/api"
 	db "github.com/elmas23/simplebank/db/sqlc"
 	"github.com/elmas23/simplebank/db/utils"
+	"github.com/elmas23/simplebank/fee"
+	"github.com/elmas23/simplebank/fx"
+	"github.com/elmas23/simplebank/scheduler"
 	_ "github.com/lib/pq"
 )
 
@@ -28,10 +34,68 @@ func main() {
 		log.Fatal("cannot connect to db:", err)
 	}
 
+	// the transactions that don't declare their own isolation level use the one of the config
+	isolationLevel, err := db.ParseIsolationLevel(config.DBIsolationLevel)
+	if err != nil {
+		log.Fatal("cannot parse isolation level:", err)
+	}
+
+	storeOptions := []db.StoreOption{db.WithDefaultIsolation(isolationLevel)}
+
+	// the exchange rates are read from a file, so the server can run without access to a rate service
+	// without them, money can only be transferred between accounts of the same currency
+	if len(config.FXRatesFile) > 0 {
+		rates, err := fx.NewFileProvider(config.FXRatesFile)
+		if err != nil {
+			log.Fatal("cannot load exchange rates:", err)
+		}
+		storeOptions = append(storeOptions, db.WithRateProvider(rates))
+	}
+
+	// the fees are also read from a file, without it the transfers are free
+	if len(config.FeeRulesFile) > 0 {
+		fees, err := fee.NewFileSchedule(config.FeeRulesFile)
+		if err != nil {
+			log.Fatal("cannot load fee rules:", err)
+		}
+		storeOptions = append(storeOptions, db.WithFeeSchedule(fees))
+	}
+
+	// a transfer that needs an approval expires if nobody approves it in time, the store has a default
+	if config.TransferApprovalExpiry > 0 {
+		storeOptions = append(storeOptions, db.WithApprovalExpiry(config.TransferApprovalExpiry))
+	}
+
+	// a hold that is not captured in time gives its money back, the store has a default
+	if config.HoldExpiry > 0 {
+		storeOptions = append(storeOptions, db.WithHoldExpiry(config.HoldExpiry))
+	}
+
 	// creating a store
-	store := db.NewStore(conn)
+	store := db.NewStore(conn, storeOptions...)
+
+	// "go run main.go reconcile" checks the ledger instead of starting the server
+	// it can be run by a cron job, since it exits with 1 when the ledger has discrepancies
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		runReconcile(store)
+		return
+	}
+
+	// the scheduler makes the runs of the standing orders and expires the holds and the pending transfers in the background, while the server handles the requests
+	// every instance of the server runs one, they never execute the same run twice, see ExecuteDueStandingOrderTx
+	if config.StandingOrderPollInterval > 0 {
+		retryPolicy := db.StandingOrderRetryPolicy{
+			MaxAttempts: config.StandingOrderMaxAttempts,
+			RetryDelay:  config.StandingOrderRetryDelay,
+		}
+		go scheduler.NewScheduler(store, config.StandingOrderPollInterval, retryPolicy).Start(context.Background())
+	}
+
 	// creating a server
-	server := api.NewServer(store)
+	server, err := api.NewServer(config, store)
+	if err != nil {
+		log.Fatal("cannot create server:", err)
+	}
 
 	// Starting our server
 	err = server.Start(config.ServerAddress)
@@ -39,3 +103,21 @@ func main() {
 		log.Fatal("cannot start server:", err)
 	}
 }
+
+// runReconcile prints the reconciliation report of the ledger as JSON on the standard output
+func runReconcile(store db.Store) {
+	report, err := store.ReconcileLedger(context.Background())
+	if err != nil {
+		log.Fatal("cannot reconcile ledger:", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatal("cannot print reconciliation report:", err)
+	}
+
+	if report.HasDiscrepancies() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,129 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"github.com/elmas23/simplebank/currency"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/*
+Why do we need a money type ?
+
+		The amounts used to be plain int64 values. Nothing told in which unit they were,
+		nothing stopped us from adding dollars to euros, and an addition could silently overflow.
+
+		- Amount is an integer number of minor units, like cents. It is the type of every amount column
+		  of the database (sqlc uses it thanks to the overrides of sqlc.yaml), and its additions are checked.
+		- Money is an Amount with its currency. Two Money values can only be added if they have the same currency,
+		  and they are sent as JSON as a decimal string, with the minor units of their currency, like "12.34".
+*/
+
+var (
+	ErrOverflow         = errors.New("amount overflows")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidAmount    = errors.New("invalid amount")
+)
+
+// Amount is an amount in the minor unit of its currency
+// It is an int64, so database/sql reads and writes it like a bigint
+type Amount int64
+
+// Add returns a + b, or ErrOverflow if the result doesn't fit in an int64
+func (a Amount) Add(b Amount) (Amount, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, fmt.Errorf("%w: %d + %d", ErrOverflow, a, b)
+	}
+	return a + b, nil
+}
+
+// Sub returns a - b, or ErrOverflow if the result doesn't fit in an int64
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+		return 0, fmt.Errorf("%w: %d - %d", ErrOverflow, a, b)
+	}
+	return a - b, nil
+}
+
+// Neg returns -a, or ErrOverflow for the smallest int64 which has no opposite
+func (a Amount) Neg() (Amount, error) {
+	if a == math.MinInt64 {
+		return 0, fmt.Errorf("%w: -(%d)", ErrOverflow, a)
+	}
+	return -a, nil
+}
+
+// Money is an amount with its currency
+type Money struct {
+	Amount   Amount
+	Currency string
+}
+
+// New creates a new Money
+func New(amount Amount, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Add returns m + other, they must have the same currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	amount, err := m.Amount.Add(other.Amount)
+	return New(amount, m.Currency), err
+}
+
+// Sub returns m - other, they must have the same currency
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	amount, err := m.Amount.Sub(other.Amount)
+	return New(amount, m.Currency), err
+}
+
+// Neg returns -m
+func (m Money) Neg() (Money, error) {
+	amount, err := m.Amount.Neg()
+	return New(amount, m.Currency), err
+}
+
+// Decimal returns the amount as a decimal string, with the minor units of the currency, like "12.34"
+func (m Money) Decimal() string {
+	return currency.Format(int64(m.Amount), m.Currency)
+}
+
+// String returns the amount with its currency, like "12.34 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Parse reads an amount written as a decimal string with the minor units of the currency
+// It accepts fewer decimals than the currency has, like "12.5" for 12.50 USD, but not more,
+// since that would be a fraction of a cent
+func Parse(value string, code string) (Money, error) {
+	minorUnits := currency.MinorUnits(code)
+
+	integer, fraction, hasPoint := strings.Cut(value, ".")
+	if hasPoint && (len(fraction) == 0 || len(fraction) > minorUnits) {
+		return Money{}, fmt.Errorf("%w: %q has too many decimals for %s", ErrInvalidAmount, value, code)
+	}
+	if len(strings.TrimLeft(integer, "+-")) == 0 || strings.ContainsAny(fraction, "+-") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	digits := integer + fraction + strings.Repeat("0", minorUnits-len(fraction))
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+	return New(Amount(amount), code), nil
+}
+
+// MarshalJSON sends the amount as a decimal string, like "12.34"
+// a string keeps every digit, while a JSON number may be read as a float by the client
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.Decimal())), nil
+}
@@ -0,0 +1,99 @@
+package money
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestAmount(t *testing.T) {
+	amount, err := Amount(10).Add(5)
+	require.NoError(t, err)
+	require.Equal(t, Amount(15), amount)
+
+	amount, err = Amount(10).Sub(15)
+	require.NoError(t, err)
+	require.Equal(t, Amount(-5), amount)
+
+	amount, err = Amount(10).Neg()
+	require.NoError(t, err)
+	require.Equal(t, Amount(-10), amount)
+
+	_, err = Amount(math.MaxInt64).Add(1)
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = Amount(math.MinInt64).Add(-1)
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = Amount(math.MinInt64).Sub(1)
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = Amount(math.MaxInt64).Sub(-1)
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = Amount(math.MinInt64).Neg()
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestMoney(t *testing.T) {
+	usd := New(1234, "USD")
+
+	sum, err := usd.Add(New(66, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, New(1300, "USD"), sum)
+
+	difference, err := usd.Sub(New(2000, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, New(-766, "USD"), difference)
+
+	// dollars cannot be added to euros
+	_, err = usd.Add(New(1, "EUR"))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = usd.Sub(New(1, "EUR"))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	require.Equal(t, "12.34", usd.Decimal())
+	require.Equal(t, "12.34 USD", usd.String())
+	require.Equal(t, "1234", New(1234, "JPY").Decimal())
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		value    string
+		code     string
+		expected Amount
+	}{
+		{"12.34", "USD", 1234},
+		{"12.5", "USD", 1250},
+		{"12", "USD", 1200},
+		{"-0.05", "USD", -5},
+		{"1234", "JPY", 1234},
+		{"1.234", "KWD", 1234},
+	}
+	for _, tc := range testCases {
+		m, err := Parse(tc.value, tc.code)
+		require.NoError(t, err)
+		require.Equal(t, New(tc.expected, tc.code), m)
+
+		// a parsed amount is formatted back to the same value, once padded
+		parsed, err := Parse(m.Decimal(), tc.code)
+		require.NoError(t, err)
+		require.Equal(t, m, parsed)
+	}
+
+	for _, value := range []string{"", "-", "-.5", "abc", "12.345", "12.", "1.2.3", "1.-5", "99999999999999999999"} {
+		_, err := Parse(value, "USD")
+		require.ErrorIs(t, err, ErrInvalidAmount)
+	}
+
+	_, err := Parse("12.3", "JPY")
+	require.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestMoneyMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(New(-1234, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, `"-12.34"`, string(data))
+}
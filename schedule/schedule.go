@@ -0,0 +1,80 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// These are the frequencies of a schedule, a schedule runs every Interval days, weeks or months
+// For example, the rent paid on the 1st of every month is a monthly schedule with an interval of 1
+// that starts on the 1st of a month
+const (
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+)
+
+var (
+	ErrInvalidSchedule = errors.New("invalid schedule")
+)
+
+// Schedule is a recurring date, like the interval of a cron job
+// The runs are always computed from Start, so they never drift: a monthly schedule that starts
+// on the 31st runs on the 30th in the months of 30 days, and again on the 31st the month after
+type Schedule struct {
+	Frequency string
+	Interval  int32
+	Start     time.Time
+	End       *time.Time // the last run is at End or before, the schedule never ends when it is nil
+}
+
+// Validate checks that the schedule has a known frequency, a positive interval and ends after it starts
+func (schedule Schedule) Validate() error {
+	switch schedule.Frequency {
+	case Daily, Weekly, Monthly:
+	default:
+		return fmt.Errorf("%w: unknown frequency %q", ErrInvalidSchedule, schedule.Frequency)
+	}
+	if schedule.Interval <= 0 {
+		return fmt.Errorf("%w: interval must be positive", ErrInvalidSchedule)
+	}
+	if schedule.End != nil && schedule.End.Before(schedule.Start) {
+		return fmt.Errorf("%w: end must be after start", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// Run returns the time of the n-th run, the first one is n = 0 and is at Start
+// The second result is false if that run is after End, the schedule is then over
+func (schedule Schedule) Run(n int64) (time.Time, bool) {
+	units := int(n) * int(schedule.Interval)
+
+	var run time.Time
+	switch schedule.Frequency {
+	case Daily:
+		run = schedule.Start.AddDate(0, 0, units)
+	case Weekly:
+		run = schedule.Start.AddDate(0, 0, 7*units)
+	default:
+		run = addMonths(schedule.Start, units)
+	}
+
+	if schedule.End != nil && run.After(*schedule.End) {
+		return run, false
+	}
+	return run, true
+}
+
+// addMonths adds a number of months to a time, without overflowing into the next month
+// time.AddDate normalizes January 31 + 1 month into March 3, here it is February 28 or 29
+func addMonths(start time.Time, months int) time.Time {
+	year, month, day := start.Date()
+	// the day 0 of the month after is the last day of the month
+	lastDay := time.Date(year, month+time.Month(months)+1, 0, 0, 0, 0, 0, start.Location()).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(year, month+time.Month(months), day,
+		start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+}
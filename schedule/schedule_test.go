@@ -0,0 +1,63 @@
+package schedule
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestScheduleRun(t *testing.T) {
+	start := time.Date(2023, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		frequency string
+		interval  int32
+		n         int64
+		run       time.Time
+	}{
+		{"First", Monthly, 1, 0, start},
+		{"Daily", Daily, 1, 3, time.Date(2023, time.February, 3, 9, 0, 0, 0, time.UTC)},
+		{"EveryTwoWeeks", Weekly, 2, 2, time.Date(2023, time.February, 28, 9, 0, 0, 0, time.UTC)},
+		{"MonthlyShortMonth", Monthly, 1, 1, time.Date(2023, time.February, 28, 9, 0, 0, 0, time.UTC)},
+		{"MonthlyDoesNotDrift", Monthly, 1, 2, time.Date(2023, time.March, 31, 9, 0, 0, 0, time.UTC)},
+		{"Quarterly", Monthly, 3, 1, time.Date(2023, time.April, 30, 9, 0, 0, 0, time.UTC)},
+		{"NextYear", Monthly, 1, 13, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			schedule := Schedule{Frequency: tc.frequency, Interval: tc.interval, Start: start}
+			require.NoError(t, schedule.Validate())
+
+			run, ok := schedule.Run(tc.n)
+			require.True(t, ok)
+			require.Equal(t, tc.run, run)
+		})
+	}
+}
+
+func TestScheduleEnd(t *testing.T) {
+	start := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+	schedule := Schedule{Frequency: Monthly, Interval: 1, Start: start, End: &end}
+
+	// the end is included
+	run, ok := schedule.Run(2)
+	require.True(t, ok)
+	require.Equal(t, end, run)
+
+	_, ok = schedule.Run(3)
+	require.False(t, ok)
+}
+
+func TestScheduleValidate(t *testing.T) {
+	start := time.Now()
+	before := start.Add(-time.Hour)
+
+	require.ErrorIs(t, Schedule{Frequency: "yearly", Interval: 1, Start: start}.Validate(), ErrInvalidSchedule)
+	require.ErrorIs(t, Schedule{Frequency: Daily, Interval: 0, Start: start}.Validate(), ErrInvalidSchedule)
+	require.ErrorIs(t, Schedule{Frequency: Daily, Interval: 1, Start: start, End: &before}.Validate(), ErrInvalidSchedule)
+}
@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "github.com/elmas23/simplebank/db/sqlc"
+	"log"
+	"time"
+)
+
+// Scheduler runs the standing orders when they are due, see ExecuteDueStandingOrderTx,
+// gives back the money of the holds that expired, see ExpireDueHoldTx,
+// and expires the pending transfers that were not reviewed in time, see ExpireDuePendingTransferTx
+// Every server of the bank can run its own scheduler: each of them is claimed with SKIP LOCKED,
+// so the servers share the work between them, and a run is never made twice
+type Scheduler struct {
+	store        db.Store
+	pollInterval time.Duration               // how often the scheduler looks for due standing orders and expired holds or transfers
+	retryPolicy  db.StandingOrderRetryPolicy // what to do when the transfer of a run fails
+}
+
+// NewScheduler creates a scheduler for the standing orders of the store
+func NewScheduler(store db.Store, pollInterval time.Duration, retryPolicy db.StandingOrderRetryPolicy) *Scheduler {
+	return &Scheduler{
+		store:        store,
+		pollInterval: pollInterval,
+		retryPolicy:  retryPolicy,
+	}
+}
+
+// Start expires the holds and the pending transfers, and runs the due standing orders,
+// then again every poll interval, until the context is done
+// The holds are expired first, so a standing order can use the money that they give back
+// It is meant to run in its own goroutine, the errors are only logged so that the next poll tries again
+func (scheduler *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(scheduler.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		expired, err := scheduler.ExpireHolds(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Println("cannot expire holds:", err)
+		}
+		if expired > 0 {
+			log.Printf("expired %d holds", expired)
+		}
+
+		expired, err = scheduler.ExpirePendingTransfers(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Println("cannot expire pending transfers:", err)
+		}
+		if expired > 0 {
+			log.Printf("expired %d pending transfers", expired)
+		}
+
+		runs, err := scheduler.RunDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Println("cannot run standing orders:", err)
+		}
+		if runs > 0 {
+			log.Printf("ran %d standing orders", runs)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue executes the due standing orders one after the other, until none of them is left
+// It returns the number of standing orders that were executed, a failed transfer is counted too since its attempt is saved
+// A standing order that moves to a run that is already due, like after the server was stopped for a while, is executed again
+func (scheduler *Scheduler) RunDue(ctx context.Context) (int, error) {
+	runs := 0
+	for {
+		_, err := scheduler.store.ExecuteDueStandingOrderTx(ctx, db.ExecuteStandingOrderTxParams{
+			Now:         time.Now(),
+			RetryPolicy: scheduler.retryPolicy,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return runs, nil
+		}
+		if err != nil {
+			return runs, err
+		}
+		runs++
+	}
+}
+
+// ExpireHolds expires the active holds that were not captured in time, one after the other, until none of them is left
+// It returns the number of holds that were expired
+func (scheduler *Scheduler) ExpireHolds(ctx context.Context) (int, error) {
+	expired := 0
+	for {
+		_, err := scheduler.store.ExpireDueHoldTx(ctx, time.Now())
+		if errors.Is(err, sql.ErrNoRows) {
+			return expired, nil
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired++
+	}
+}
+
+// ExpirePendingTransfers expires the pending transfers that were not executed in time, one after the other,
+// until none of them is left, so they don't stay in the list of the bankers as pending forever
+// It returns the number of pending transfers that were expired
+func (scheduler *Scheduler) ExpirePendingTransfers(ctx context.Context) (int, error) {
+	expired := 0
+	for {
+		_, err := scheduler.store.ExpireDuePendingTransferTx(ctx, time.Now())
+		if errors.Is(err, sql.ErrNoRows) {
+			return expired, nil
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired++
+	}
+}
@@ -0,0 +1,185 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	mockdb "github.com/elmas23/simplebank/db/mock"
+	db "github.com/elmas23/simplebank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRunDue(t *testing.T) {
+	retryPolicy := db.StandingOrderRetryPolicy{MaxAttempts: 3, RetryDelay: time.Hour}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, runs int, err error)
+	}{
+		{
+			name: "NothingDue",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ExecuteDueStandingOrderTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.StandingOrderTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, runs int, err error) {
+				require.NoError(t, err)
+				require.Zero(t, runs)
+			},
+		},
+		{
+			name: "UntilNothingDue",
+			buildStubs: func(store *mockdb.MockStore) {
+				// the retry policy of the scheduler is passed to every execution
+				execute := func(_ context.Context, arg db.ExecuteStandingOrderTxParams) (db.StandingOrderTxResult, error) {
+					require.Equal(t, retryPolicy, arg.RetryPolicy)
+					require.WithinDuration(t, time.Now(), arg.Now, time.Second)
+					return db.StandingOrderTxResult{}, nil
+				}
+				gomock.InOrder(
+					store.EXPECT().
+						ExecuteDueStandingOrderTx(gomock.Any(), gomock.Any()).
+						Times(2).
+						DoAndReturn(execute),
+					store.EXPECT().
+						ExecuteDueStandingOrderTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.StandingOrderTxResult{}, sql.ErrNoRows),
+				)
+			},
+			checkResponse: func(t *testing.T, runs int, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 2, runs)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().
+						ExecuteDueStandingOrderTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.StandingOrderTxResult{}, nil),
+					store.EXPECT().
+						ExecuteDueStandingOrderTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.StandingOrderTxResult{}, sql.ErrConnDone),
+				)
+			},
+			checkResponse: func(t *testing.T, runs int, err error) {
+				require.True(t, errors.Is(err, sql.ErrConnDone))
+				require.Equal(t, 1, runs)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			scheduler := NewScheduler(store, time.Minute, retryPolicy)
+			runs, err := scheduler.RunDue(context.Background())
+			tc.checkResponse(t, runs, err)
+		})
+	}
+}
+
+func TestStartStopsWithContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the context is cancelled by the first execution, so Start must return after it
+	ctx, cancel := context.WithCancel(context.Background())
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ExpireDueHoldTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.Hold{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExpireDuePendingTransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.PendingTransfer{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExecuteDueStandingOrderTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, _ db.ExecuteStandingOrderTxParams) (db.StandingOrderTxResult, error) {
+			cancel()
+			return db.StandingOrderTxResult{}, context.Canceled
+		})
+
+	done := make(chan struct{})
+	go func() {
+		NewScheduler(store, time.Hour, db.StandingOrderRetryPolicy{MaxAttempts: 1}).Start(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not stop")
+	}
+}
+
+func TestExpireHolds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the holds are expired until none of them is left
+	store := mockdb.NewMockStore(ctrl)
+	expire := func(_ context.Context, now time.Time) (db.Hold, error) {
+		require.WithinDuration(t, time.Now(), now, time.Second)
+		return db.Hold{}, nil
+	}
+	gomock.InOrder(
+		store.EXPECT().
+			ExpireDueHoldTx(gomock.Any(), gomock.Any()).
+			Times(3).
+			DoAndReturn(expire),
+		store.EXPECT().
+			ExpireDueHoldTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.Hold{}, sql.ErrNoRows),
+	)
+
+	expired, err := NewScheduler(store, time.Minute, db.StandingOrderRetryPolicy{}).ExpireHolds(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, expired)
+}
+
+func TestExpirePendingTransfers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the pending transfers are expired until none of them is left
+	store := mockdb.NewMockStore(ctrl)
+	expire := func(_ context.Context, now time.Time) (db.PendingTransfer, error) {
+		require.WithinDuration(t, time.Now(), now, time.Second)
+		return db.PendingTransfer{}, nil
+	}
+	gomock.InOrder(
+		store.EXPECT().
+			ExpireDuePendingTransferTx(gomock.Any(), gomock.Any()).
+			Times(2).
+			DoAndReturn(expire),
+		store.EXPECT().
+			ExpireDuePendingTransferTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.PendingTransfer{}, sql.ErrConnDone),
+	)
+
+	expired, err := NewScheduler(store, time.Minute, db.StandingOrderRetryPolicy{}).ExpirePendingTransfers(context.Background())
+	require.True(t, errors.Is(err, sql.ErrConnDone))
+	require.Equal(t, 2, expired)
+}
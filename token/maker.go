@@ -0,0 +1,16 @@
+package token
+
+import "time"
+
+// Maker is an interface for managing tokens
+// The server only depends on this interface, so we can easily switch
+// between the different implementations (JWT or PASETO)
+type Maker interface {
+	// CreateToken creates a new token for a specific username, role, duration and type
+	// The payload is also returned so that the caller knows the ID and the expiration time of the token
+	CreateToken(username string, role string, duration time.Duration, tokenType TokenType) (string, *Payload, error)
+
+	// VerifyToken checks if the token is valid and of the expected type
+	// If it is valid, it returns the payload data stored inside the body of the token
+	VerifyToken(token string, tokenType TokenType) (*Payload, error)
+}
@@ -0,0 +1,63 @@
+package token
+
+import (
+	"fmt"
+	"github.com/aead/chacha20poly1305"
+	"github.com/o1egl/paseto"
+	"time"
+)
+
+// PasetoMaker is a PASETO token maker
+// We use the version 2 local tokens, which are encrypted and authenticated
+// with XChaCha20-Poly1305 using a symmetric key
+type PasetoMaker struct {
+	paseto       *paseto.V2
+	symmetricKey []byte
+}
+
+// NewPasetoMaker creates a new PasetoMaker
+func NewPasetoMaker(symmetricKey string) (Maker, error) {
+	if len(symmetricKey) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
+	}
+
+	maker := &PasetoMaker{
+		paseto:       paseto.NewV2(),
+		symmetricKey: []byte(symmetricKey),
+	}
+	return maker, nil
+}
+
+// CreateToken creates a new token for a specific username, role, duration and type
+func (maker *PasetoMaker) CreateToken(username string, role string, duration time.Duration, tokenType TokenType) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration, tokenType)
+	if err != nil {
+		return "", payload, err
+	}
+
+	token, err := maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
+	return token, payload, err
+}
+
+// VerifyToken checks if the token is valid and of the expected type
+func (maker *PasetoMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
+	payload := &Payload{}
+
+	err := maker.paseto.Decrypt(token, maker.symmetricKey, payload, nil)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// PASETO does not check the expiration for us, so we call Valid() ourselves
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	err = payload.VerifyType(tokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,72 @@
+package token
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Different types of error returned by the VerifyToken function
+var (
+	ErrInvalidToken = errors.New("token is invalid")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// TokenType tells what a token can be used for
+// Both types are made by the same maker, so without it a refresh token would also be accepted as an access token
+type TokenType byte
+
+const (
+	// TokenTypeAccessToken is the short-lived token sent with every request
+	TokenTypeAccessToken TokenType = 1
+	// TokenTypeRefreshToken is the long-lived token of a session, it can only renew the access token or log out
+	TokenTypeRefreshToken TokenType = 2
+)
+
+// Payload contains the payload data of the token
+// The ID is a unique identifier of each token
+// for refresh tokens it is also the ID of the session, that's how we can invalidate a specific token
+type Payload struct {
+	ID        uuid.UUID `json:"id"`
+	Type      TokenType `json:"token_type"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// NewPayload creates a new token payload with a specific username, role, duration and type
+func NewPayload(username string, role string, duration time.Duration, tokenType TokenType) (*Payload, error) {
+	tokenID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &Payload{
+		ID:        tokenID,
+		Type:      tokenType,
+		Username:  username,
+		Role:      role,
+		IssuedAt:  time.Now(),
+		ExpiredAt: time.Now().Add(duration),
+	}
+	return payload, nil
+}
+
+// Valid checks if the token payload is valid or not
+// This method is also needed for the Payload to implement the jwt.Claims interface
+func (payload *Payload) Valid() error {
+	if time.Now().After(payload.ExpiredAt) {
+		return ErrExpiredToken
+	}
+	return nil
+}
+
+// VerifyType checks that the token is of the expected type
+// ErrInvalidToken is returned otherwise, the caller doesn't need to know more than that
+func (payload *Payload) VerifyType(tokenType TokenType) error {
+	if payload.Type != tokenType {
+		return ErrInvalidToken
+	}
+	return nil
+}